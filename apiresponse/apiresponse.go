@@ -0,0 +1,176 @@
+package apiresponse
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+type Response struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Data      interface{}  `json:"data,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	Meta      *Meta        `json:"meta,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+type Meta struct {
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// ICodedError error carrying application error code, eg: NOT_FOUND
+type ICodedError interface {
+	error
+	Code() string
+}
+
+// IFieldError error carrying validation errors per field
+type IFieldError interface {
+	error
+	FieldErrors() []FieldError
+}
+
+const (
+	CodeSuccess            = "SUCCESS"
+	CodeBadRequest         = "BAD_REQUEST"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotFound           = "NOT_FOUND"
+	CodeConflict           = "CONFLICT"
+	CodeValidation         = "VALIDATION_ERROR"
+	CodeTooManyRequests    = "TOO_MANY_REQUESTS"
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+)
+
+// RequestIDKey context key of request id, same key should be passed to log.InitLogger context data
+var RequestIDKey = "request_id"
+
+var (
+	mu       sync.RWMutex
+	statuses = map[string]int{
+		CodeSuccess:            http.StatusOK,
+		CodeBadRequest:         http.StatusBadRequest,
+		CodeUnauthorized:       http.StatusUnauthorized,
+		CodeForbidden:          http.StatusForbidden,
+		CodeNotFound:           http.StatusNotFound,
+		CodeConflict:           http.StatusConflict,
+		CodeValidation:         http.StatusUnprocessableEntity,
+		CodeTooManyRequests:    http.StatusTooManyRequests,
+		CodeInternal:           http.StatusInternalServerError,
+		CodeServiceUnavailable: http.StatusServiceUnavailable,
+	}
+)
+
+// RegisterCode map application error code into http status
+func RegisterCode(code string, status int) {
+	mu.Lock()
+	defer mu.Unlock()
+	statuses[code] = status
+}
+
+// StatusOf return http status of error code, unknown code is internal server error
+func StatusOf(code string) int {
+	mu.RLock()
+	defer mu.RUnlock()
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func requestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if value, ok := ctx.Value(RequestIDKey).(string); ok {
+		return value
+	}
+	return ""
+}
+
+// Write render response envelope as json
+func Write(ctx context.Context, w http.ResponseWriter, status int, response Response) {
+	response.RequestID = requestID(ctx)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithContext(ctx).Errorf("Failed to write response. Error: %s", err)
+	}
+}
+
+func OK(ctx context.Context, w http.ResponseWriter, data interface{}) {
+	Write(ctx, w, http.StatusOK, Response{Code: CodeSuccess, Message: "OK", Data: data})
+}
+
+// OKWithPagination render list data with pagination meta
+func OKWithPagination(ctx context.Context, w http.ResponseWriter, data interface{}, page, perPage int, total int64) {
+	Write(ctx, w, http.StatusOK, Response{
+		Code:    CodeSuccess,
+		Message: "OK",
+		Data:    data,
+		Meta:    NewMeta(page, perPage, total),
+	})
+}
+
+func Created(ctx context.Context, w http.ResponseWriter, data interface{}) {
+	Write(ctx, w, http.StatusCreated, Response{Code: CodeSuccess, Message: "Created", Data: data})
+}
+
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Fail render error response, http status is taken from error code mapping
+func Fail(ctx context.Context, w http.ResponseWriter, code, message string, errs ...FieldError) {
+	Write(ctx, w, StatusOf(code), Response{Code: code, Message: message, Errors: errs})
+}
+
+// FailFromError render error response from err code, error without code is internal error
+// and its message is hidden from client
+func FailFromError(ctx context.Context, w http.ResponseWriter, err error) {
+	var fieldErrors []FieldError
+	var fieldErr IFieldError
+	if errors.As(err, &fieldErr) {
+		fieldErrors = fieldErr.FieldErrors()
+	}
+
+	var codedErr ICodedError
+	switch {
+	case errors.As(err, &codedErr):
+		Fail(ctx, w, codedErr.Code(), codedErr.Error(), fieldErrors...)
+	case errors.Is(err, sql.ErrNoRows):
+		Fail(ctx, w, CodeNotFound, "Data not found", fieldErrors...)
+	case errors.Is(err, context.DeadlineExceeded):
+		Fail(ctx, w, CodeServiceUnavailable, "Request timeout", fieldErrors...)
+	case len(fieldErrors) > 0:
+		Fail(ctx, w, CodeValidation, err.Error(), fieldErrors...)
+	default:
+		log.WithContext(ctx).Errorf("Internal error. Error: %s", err)
+		Fail(ctx, w, CodeInternal, "Internal server error")
+	}
+}
+
+func NewMeta(page, perPage int, total int64) *Meta {
+	totalPages := 0
+	if perPage > 0 {
+		totalPages = int((total + int64(perPage) - 1) / int64(perPage))
+	}
+	return &Meta{Page: page, PerPage: perPage, Total: total, TotalPages: totalPages}
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// ErrModuleNotLoaded command belong to redis module which is not loaded on the server, eg: BF.ADD without RedisBloom
+var ErrModuleNotLoaded = errors.New("redis module is not loaded")
+
+// HasModule check module is loaded with MODULE LIST, eg: "bf" for RedisBloom. bloom and cuckoo commands return
+// ErrModuleNotLoaded anyway so this is only needed to pick fallback up front, eg: on startup
+func (r *Redis) HasModule(ctx context.Context, name string) (bool, error) {
+	modules, err := r.Do(ctx, "MODULE", "LIST").Values()
+	if errors.Is(moduleError(err), ErrModuleNotLoaded) {
+		// server without module support, eg: redis before 4.0
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	for _, module := range modules {
+		fields, err := redis.Values(module, nil)
+		if err != nil {
+			return false, err
+		}
+		for i := 0; i+1 < len(fields); i += 2 {
+			if key, _ := redis.String(fields[i], nil); key != "name" {
+				continue
+			}
+			if value, _ := redis.String(fields[i+1], nil); strings.EqualFold(value, name) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// BFReserve create bloom filter for capacity items with false positive errorRate, eg: 0.001.
+// BFAdd create filter with server default capacity and error rate when it does not exist
+func (r *Redis) BFReserve(ctx context.Context, key string, errorRate float64, capacity int64) IReply {
+	reply := r.do(ctx, r.timeout, "BF.RESERVE", []interface{}{key, errorRate, capacity})
+	reply.error = moduleError(reply.error)
+	return reply
+}
+
+// BFAdd add item to bloom filter, false is returned when item may already exist
+func (r *Redis) BFAdd(ctx context.Context, key, item string) (bool, error) {
+	return r.moduleBool(ctx, "BF.ADD", key, item)
+}
+
+// BFMAdd add items to bloom filter, result is in the same order as items, see BFAdd
+func (r *Redis) BFMAdd(ctx context.Context, key string, items ...string) ([]bool, error) {
+	return r.moduleBools(ctx, "BF.MADD", redis.Args{}.Add(key).AddFlat(items)...)
+}
+
+// BFExists check item may exist in bloom filter, false means item was never added
+func (r *Redis) BFExists(ctx context.Context, key, item string) (bool, error) {
+	return r.moduleBool(ctx, "BF.EXISTS", key, item)
+}
+
+// BFMExists check items may exist in bloom filter, result is in the same order as items
+func (r *Redis) BFMExists(ctx context.Context, key string, items ...string) ([]bool, error) {
+	return r.moduleBools(ctx, "BF.MEXISTS", redis.Args{}.Add(key).AddFlat(items)...)
+}
+
+// CFReserve create cuckoo filter for capacity items. unlike bloom filter item can be deleted
+func (r *Redis) CFReserve(ctx context.Context, key string, capacity int64) IReply {
+	reply := r.do(ctx, r.timeout, "CF.RESERVE", []interface{}{key, capacity})
+	reply.error = moduleError(reply.error)
+	return reply
+}
+
+// CFAdd add item to cuckoo filter even when it already exist, so it can be deleted as many times as it was added
+func (r *Redis) CFAdd(ctx context.Context, key, item string) (bool, error) {
+	return r.moduleBool(ctx, "CF.ADD", key, item)
+}
+
+// CFAddNX add item to cuckoo filter only when it does not exist, false is returned when item may already exist
+func (r *Redis) CFAddNX(ctx context.Context, key, item string) (bool, error) {
+	return r.moduleBool(ctx, "CF.ADDNX", key, item)
+}
+
+// CFExists check item may exist in cuckoo filter
+func (r *Redis) CFExists(ctx context.Context, key, item string) (bool, error) {
+	return r.moduleBool(ctx, "CF.EXISTS", key, item)
+}
+
+// CFDel delete one occurrence of item from cuckoo filter, false is returned when item is not found.
+// deleting item which was never added may delete other item sharing its fingerprint
+func (r *Redis) CFDel(ctx context.Context, key, item string) (bool, error) {
+	return r.moduleBool(ctx, "CF.DEL", key, item)
+}
+
+func (r *Redis) moduleBool(ctx context.Context, command string, args ...interface{}) (bool, error) {
+	reply, err := r.Do(ctx, command, args...).Int()
+	return reply == 1, moduleError(err)
+}
+
+func (r *Redis) moduleBools(ctx context.Context, command string, args ...interface{}) ([]bool, error) {
+	replies, err := r.Do(ctx, command, args...).Ints()
+	if err != nil {
+		return nil, moduleError(err)
+	}
+	result := make([]bool, len(replies))
+	for i, reply := range replies {
+		result[i] = reply == 1
+	}
+	return result, nil
+}
+
+// moduleError wrap unknown command error with ErrModuleNotLoaded
+func moduleError(err error) error {
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+		return fmt.Errorf("%w: %s", ErrModuleNotLoaded, err.Error())
+	}
+	return err
+}
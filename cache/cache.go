@@ -2,31 +2,102 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/garyburd/redigo/redis"
+	goredis "github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
-//-------------------
+// -------------------
 type RedisConfig struct {
 	Connection string
-	Password   string
-	Timeout    int
-	MaxIdle    int
-	MaxActive  int
+	// redis 6 ACL user, by default password is sent with legacy AUTH as default user
+	Username string
+	Password string
+	// database index selected after connect, by default 0
+	DB        int
+	Timeout   int
+	MaxIdle   int
+	MaxActive int
+	// encoding of struct commands, by default JSONCodec
+	Codec Codec
+	// prepended to every key of command, eg: "svc-orders:" so services can share one redis, by default no prefix
+	KeyPrefix string
+	// expire of Set, SetStruct, SAdd and HSet, negative never expire, by default 15 minutes
+	DefaultTTL time.Duration
+
+	// name of the cache used as cache label in metrics, by default redis
+	Name string
+	// hooks called around every command in registration order, by default there is no hook
+	Hooks []Hook
+	// export command latency, errors and hit ratio through Collector, by default metrics is disabled
+	EnableMetrics bool
+	// start opentelemetry span per command using global tracer provider, by default tracing is disabled
+	EnableTracing bool
+	// record SHA-256 of key in span instead of the key, by default key is recorded as is
+	HashTracedKeys bool
+	// retry command on transient error, commands of Pipeline and Tx are not retried, by default command is not retried
+	Retry RetryConfig
+}
+
+type Config struct {
+	// redis, goredis or memcached, by default redis
+	Driver    string
+	Redis     RedisConfig
+	GoRedis   GoRedisConfig
+	Memcached MemcachedConfig
 }
 
 type Redis struct {
 	connection string
 	timeout    time.Duration
 	pool       *redis.Pool
+	// database selected by pooled connection, see SubscribeKeyEvents
+	db    int
+	codec Codec
+	// prepended to keys, see RedisConfig
+	prefix     string
+	defaultTTL time.Duration
+
+	// set when connected with ConnectRedisCluster, command is routed by key slot instead of pool
+	cluster *cluster
+	// set when connected with ConnectGoRedis, command is run by go-redis instead of pool
+	client goredis.UniversalClient
+
+	// see Hook
+	hooks []Hook
+	retry RetryConfig
+
+	// concurrent load of GetOrSet per key
+	loads singleflight.Group
 }
 
 type Reply struct {
 	result interface{}
 	error  error
+	// decode Unmarshal, by default JSONCodec
+	codec Codec
+}
+
+// SetOptions of SetOpts, zero value is plain SET without expire
+type SetOptions struct {
+	// only set when key does not exist
+	NX bool
+	// only set when key already exist
+	XX bool
+	// expire in seconds
+	EX int
+	// expire in milliseconds
+	PX int64
+	// retain ttl of existing key
+	KeepTTL bool
+	// return old value instead of OK
+	Get bool
 }
 
 const ErrorFailedConnect = "Failed to connect to redis %s. Error: %s"
@@ -34,41 +105,198 @@ const ErrorFailedConnect = "Failed to connect to redis %s. Error: %s"
 // ErrorNil redis error no data
 var ErrorNil = redis.ErrNil
 
+// ErrInvalidSetOptions SetOptions combine exclusive options, eg: NX with XX or EX with KeepTTL
+var ErrInvalidSetOptions = errors.New("invalid set options, NX and XX or EX, PX and KeepTTL are exclusive")
+
+const (
+	DriverRedis     = "redis"
+	DriverGoRedis   = "goredis"
+	DriverMemcached = "memcached"
+)
+
+// Connect open connection to cache backend selected by config driver
+func Connect(config Config) (ICache, error) {
+	switch config.Driver {
+	case "", DriverRedis:
+		return ConnectRedis(config.Redis)
+	case DriverGoRedis:
+		return ConnectGoRedis(config.GoRedis)
+	case DriverMemcached:
+		return ConnectMemcached(config.Memcached)
+	}
+	return nil, fmt.Errorf("Unsupported cache driver %s", config.Driver)
+}
+
+// dialRedis open connection then authenticate and select database, redigo dial option only support
+// legacy AUTH so ACL user is authenticated manually before SELECT
+func dialRedis(config RedisConfig, timeout time.Duration) (redis.Conn, error) {
+	if config.Username == "" {
+		return redis.Dial("tcp", config.Connection, redis.DialConnectTimeout(timeout),
+			redis.DialPassword(config.Password), redis.DialDatabase(config.DB))
+	}
+
+	conn, err := redis.Dial("tcp", config.Connection, redis.DialConnectTimeout(timeout))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = conn.Do("AUTH", config.Username, config.Password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if config.DB != 0 {
+		if _, err = conn.Do("SELECT", config.DB); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
 func ConnectRedis(config RedisConfig) (ICache, error) {
 	timeout := time.Duration(config.Timeout) * time.Second
-	pool := &redis.Pool{
+	pool := newRedisPool(config, func() (redis.Conn, error) {
+		return dialRedis(config, timeout)
+	})
+	return connectPool(pool, config.Connection, config)
+}
+
+func newRedisPool(config RedisConfig, dial func() (redis.Conn, error)) *redis.Pool {
+	return &redis.Pool{
 		MaxIdle:     config.MaxIdle,
 		MaxActive:   config.MaxActive,
-		IdleTimeout: timeout,
+		IdleTimeout: time.Duration(config.Timeout) * time.Second,
 		Wait:        true,
-		Dial: func() (redis.Conn, error) {
-			conn, err := redis.Dial("tcp", config.Connection, redis.DialConnectTimeout(timeout))
-			if err != nil {
-				return nil, err
-			}
-			return conn, err
-		},
+		Dial:        dial,
 	}
+}
 
+// connectPool check pool is reachable with PING, connection is only used in error message
+func connectPool(pool *redis.Pool, connection string, config RedisConfig) (ICache, error) {
+	timeout := time.Duration(config.Timeout) * time.Second
 	conn, _ := pool.Get().(redis.ConnWithTimeout)
+	defer conn.Close()
 	_, err := conn.DoWithTimeout(timeout, "PING")
 	if err != nil {
-		return nil, fmt.Errorf(ErrorFailedConnect, config.Connection, err)
+		pool.Close()
+		return nil, fmt.Errorf(ErrorFailedConnect, connection, err)
 	}
 
-	return &Redis{connection: config.Connection, timeout: timeout, pool: pool}, nil
+	return &Redis{connection: connection, timeout: timeout, pool: pool, db: config.DB, codec: config.Codec,
+		prefix: config.KeyPrefix, defaultTTL: defaultTTL(config.DefaultTTL), hooks: commandHooks(config), retry: config.Retry}, nil
 }
 
-func (r *Redis) getConnection() redis.ConnWithTimeout {
-	return r.pool.Get().(redis.ConnWithTimeout)
+// getConnection wait for pooled connection until ctx is done
+func (r *Redis) getConnection(ctx context.Context) (redis.ConnWithTimeout, error) {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(redis.ConnWithTimeout), nil
 }
 
+// Do run command with configured timeout shortened to ctx deadline, ctx error is returned when ctx is done
+// before command finish
 func (r *Redis) Do(ctx context.Context, command string, args ...interface{}) IReply {
-	conn := r.getConnection()
-	defer conn.Close()
+	return r.do(ctx, r.timeout, command, args)
+}
+
+// doBlocking run blocking command, eg: XREADGROUP BLOCK, timeout is extended by how long it block
+func (r *Redis) doBlocking(ctx context.Context, block time.Duration, command string, args ...interface{}) *Reply {
+	timeout := r.timeout
+	if timeout > 0 {
+		timeout += block
+	}
+	return r.do(ctx, timeout, command, args)
+}
+
+func (r *Redis) do(ctx context.Context, timeout time.Duration, command string, args []interface{}) *Reply {
+	args = r.prefixArgs(command, args)
+	var result interface{}
+	err := retry(ctx, r.retry, func() error {
+		var err error
+		result, err = r.doOnce(ctx, timeout, command, args)
+		return err
+	})
+	return &Reply{result: result, error: err, codec: codecFrom(ctx, r.codec)}
+}
+
+// doOnce run prefixed command once, every attempt of retry is observed by hooks
+func (r *Redis) doOnce(ctx context.Context, timeout time.Duration, command string, args []interface{}) (interface{}, error) {
+	timeout, err := commandTimeout(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return runHooks(ctx, r.hooks, command, args, func(ctx context.Context) (interface{}, error) {
+		return withContext(ctx, func() (interface{}, error) {
+			if r.cluster != nil {
+				return r.cluster.do(ctx, timeout, command, args)
+			}
+			if r.client != nil {
+				return r.goRedisDo(ctx, timeout, command, args)
+			}
 
-	result, err := conn.DoWithTimeout(r.timeout, command, args...)
-	return &Reply{result: result, error: err}
+			conn, err := r.getConnection(ctx)
+			if err != nil {
+				return nil, err
+			}
+			defer conn.Close()
+			return conn.DoWithTimeout(timeout, command, args...)
+		})
+	})
+}
+
+// commandTimeout return the earlier of timeout and ctx deadline, zero timeout means no timeout
+func commandTimeout(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+	if timeout > 0 && timeout < remaining {
+		return timeout, nil
+	}
+	return remaining, nil
+}
+
+// withContext run fn and return as soon as ctx is done, fn keep running in background and release its connection
+// when it finish, error caused by ctx deadline is reported as ctx error
+func withContext(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	var result interface{}
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		result, err = fn()
+	}()
+
+	select {
+	case <-done:
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// read deadline derived from ctx may fire just before ctx timer
+		if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+			return nil, context.DeadlineExceeded
+		}
+		return result, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
 func (r *Redis) Ping() error {
@@ -93,6 +321,14 @@ func (r *Redis) TTL(ctx context.Context, key string) IReply {
 func (r *Redis) Expire(ctx context.Context, key string, expire int) IReply {
 	return r.Do(ctx, "EXPIRE", key, expire)
 }
+
+// ExpireIn set expire of key with millisecond precision, zero ttl remove existing expire
+func (r *Redis) ExpireIn(ctx context.Context, key string, ttl time.Duration) IReply {
+	if ttl <= 0 {
+		return r.Do(ctx, "PERSIST", key)
+	}
+	return r.Do(ctx, "PEXPIRE", key, ttlMilliseconds(ttl))
+}
 func (r *Redis) Incr(ctx context.Context, key string) IReply {
 	return r.Do(ctx, "INCR", key)
 }
@@ -108,52 +344,264 @@ func (r *Redis) DecrBy(ctx context.Context, key string, decr int) IReply {
 func (r *Redis) Get(ctx context.Context, key string) IReply {
 	return r.Do(ctx, "GET", key)
 }
+
+// Set set key with DefaultTTL in one command
 func (r *Redis) Set(ctx context.Context, key string, value interface{}) IReply {
-	result := r.Do(ctx, "SET", key, value)
-	r.Expire(ctx, key, 15*60)
-	return result
+	return r.SetTTL(ctx, key, value, r.defaultTTL)
 }
+
+// SetWithExpire set key with expire in seconds in one command.
+// expire 0 or negative keep the key without expire, it was expired right away before expire became atomic
 func (r *Redis) SetWithExpire(ctx context.Context, key string, expire int, value interface{}) IReply {
-	result := r.Do(ctx, "SET", key, value)
-	r.Expire(ctx, key, expire)
-	return result
+	return r.SetTTL(ctx, key, value, time.Duration(expire)*time.Second)
 }
 func (r *Redis) SetNoExpire(ctx context.Context, key string, value interface{}) IReply {
 	return r.Do(ctx, "SET", key, value)
 }
+
+// SetTTL set key with millisecond precision expire in one command, zero ttl never expire
+func (r *Redis) SetTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) IReply {
+	return r.SetOpts(ctx, key, value, SetOptions{PX: ttlMilliseconds(ttl)})
+}
+
+// defaultTTL expire of commands without explicit expire, negative never expire
+func defaultTTL(ttl time.Duration) time.Duration {
+	if ttl == 0 {
+		return 15 * time.Minute
+	}
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// expireScript run ARGV[1] on KEYS[1] with ARGV[3...] and set expire of KEYS[1] to ARGV[2] milliseconds, arguments
+// are passed in chunks to stay within lua stack limit and integer replies are summed, eg: SADD.
+// error of the command abort the script before expire is set, eg: WRONGTYPE
+var expireScript = newLuaScript(1, `local reply
+for i = 3, #ARGV, 1000 do
+	local r = redis.call(ARGV[1], KEYS[1], unpack(ARGV, i, math.min(i + 999, #ARGV)))
+	if type(r) == "number" and type(reply) == "number" then
+		reply = reply + r
+	else
+		reply = r
+	end
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return reply`)
+
+// doExpire run command on key of the first arg and set its expire in one script so key is not left without
+// expire when connection fail in between, reply is the one of command. zero ttl only run command, so does command
+// without value which is rejected by redis anyway
+func (r *Redis) doExpire(ctx context.Context, ttl time.Duration, command string, args ...interface{}) IReply {
+	if ttl <= 0 || len(args) < 2 {
+		return r.Do(ctx, command, args...)
+	}
+	return expireScript.run(ctx, r, redis.Args{}.Add(args[0], command, ttlMilliseconds(ttl)).Add(args[1:]...)...)
+}
+
+// ttlMilliseconds round positive ttl shorter than a millisecond up so it is not treated as no expire
+func ttlMilliseconds(ttl time.Duration) int64 {
+	if ttl > 0 && ttl < time.Millisecond {
+		return 1
+	}
+	return ttl.Milliseconds()
+}
+
+// SetOpts set key with options in one command, reply is nil (ErrorNil) when NX or XX condition is not met
+func (r *Redis) SetOpts(ctx context.Context, key string, value interface{}, opts SetOptions) IReply {
+	args, err := opts.args(key, value)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return r.Do(ctx, "SET", args...)
+}
+
+func (o SetOptions) validate() error {
+	expires := 0
+	for _, set := range []bool{o.EX > 0, o.PX > 0, o.KeepTTL} {
+		if set {
+			expires++
+		}
+	}
+	if (o.NX && o.XX) || expires > 1 {
+		return ErrInvalidSetOptions
+	}
+	return nil
+}
+
+func (o SetOptions) args(key string, value interface{}) (redis.Args, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	args := redis.Args{}.Add(key, value)
+	if o.NX {
+		args = args.Add("NX")
+	}
+	if o.XX {
+		args = args.Add("XX")
+	}
+	if o.EX > 0 {
+		args = args.Add("EX", o.EX)
+	}
+	if o.PX > 0 {
+		args = args.Add("PX", o.PX)
+	}
+	if o.KeepTTL {
+		args = args.Add("KEEPTTL")
+	}
+	if o.Get {
+		args = args.Add("GET")
+	}
+	return args, nil
+}
+
 func (r *Redis) Del(ctx context.Context, key string) IReply {
 	return r.Do(ctx, "DEL", key)
 }
 func (r *Redis) SetStruct(ctx context.Context, key string, value interface{}) IReply {
-	jsonValue, err := json.Marshal(value)
+	encoded, err := codecFrom(ctx, r.codec).Marshal(value)
 	if err != nil {
 		return &Reply{result: nil, error: err}
 	}
-	return r.Set(ctx, key, jsonValue)
+	return r.Set(ctx, key, encoded)
 }
 func (r *Redis) SetStructWithExpire(ctx context.Context, key string, expire int, value interface{}) IReply {
-	jsonValue, err := json.Marshal(value)
+	encoded, err := codecFrom(ctx, r.codec).Marshal(value)
 	if err != nil {
 		return &Reply{result: nil, error: err}
 	}
-	return r.SetWithExpire(ctx, key, expire, jsonValue)
+	return r.SetWithExpire(ctx, key, expire, encoded)
 }
 func (r *Redis) SetStructNoExpire(ctx context.Context, key string, value interface{}) IReply {
-	jsonValue, err := json.Marshal(value)
+	encoded, err := codecFrom(ctx, r.codec).Marshal(value)
 	if err != nil {
 		return &Reply{result: nil, error: err}
 	}
-	return r.SetNoExpire(ctx, key, jsonValue)
+	return r.SetNoExpire(ctx, key, encoded)
 }
+
+// SetStructTTL see SetTTL
+func (r *Redis) SetStructTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) IReply {
+	encoded, err := codecFrom(ctx, r.codec).Marshal(value)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return r.SetTTL(ctx, key, encoded, ttl)
+}
+
+// GetDel get value and delete key atomically, eg: one-time token
+func (r *Redis) GetDel(ctx context.Context, key string) IReply {
+	return r.Do(ctx, "GETDEL", key)
+}
+
+// GetEx get value and set its expire in seconds atomically, expire 0 remove existing expire
+func (r *Redis) GetEx(ctx context.Context, key string, expire int) IReply {
+	if expire <= 0 {
+		return r.Do(ctx, "GETEX", key, "PERSIST")
+	}
+	return r.Do(ctx, "GETEX", key, "EX", expire)
+}
+
+// GetSet set value and return the old one, expire of key is removed like SET
+func (r *Redis) GetSet(ctx context.Context, key string, value interface{}) IReply {
+	return r.Do(ctx, "GETSET", key, value)
+}
+
+// MGet get value of every key in one round trip, reply has nil for missing key
+func (r *Redis) MGet(ctx context.Context, keys ...string) IReply {
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+	return r.Do(ctx, "MGET", args...)
+}
+
+// MSet set every key without expire in one round trip
+func (r *Redis) MSet(ctx context.Context, pairs map[string]interface{}) IReply {
+	args := make([]interface{}, 0, len(pairs)*2)
+	for key, value := range pairs {
+		args = append(args, key, value)
+	}
+	return r.Do(ctx, "MSET", args...)
+}
+
+// MGetStruct unmarshal value of keys into dest, pointer to slice which get one element per key,
+// missing key is left as zero value
+func (r *Redis) MGetStruct(ctx context.Context, dest interface{}, keys ...string) error {
+	return unmarshalValues(r.MGet(ctx, keys...), dest, len(keys))
+}
+
+// MSetStruct set encoded value of every key without expire
+func (r *Redis) MSetStruct(ctx context.Context, pairs map[string]interface{}) IReply {
+	encoded, err := marshalPairs(codecFrom(ctx, r.codec), pairs)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return r.MSet(ctx, encoded)
+}
+
+func marshalPairs(codec Codec, pairs map[string]interface{}) (map[string]interface{}, error) {
+	encoded := make(map[string]interface{}, len(pairs))
+	for key, value := range pairs {
+		data, err := codec.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		encoded[key] = data
+	}
+	return encoded, nil
+}
+
+// unmarshalValues unmarshal MGet reply with its codec into dest slice of n elements (every element when n is
+// negative), element of pointer type is allocated only for existing key
+func unmarshalValues(reply IReply, dest interface{}, n int) error {
+	if err := reply.Error(); err != nil {
+		return err
+	}
+	values, err := redis.ByteSlices(reply.(*Reply).result, nil)
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		n = len(values)
+	}
+
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be pointer to slice, got %T", dest)
+	}
+	slice = slice.Elem()
+	slice.Set(reflect.MakeSlice(slice.Type(), n, n))
+	for i, value := range values {
+		if value == nil || i >= n {
+			continue
+		}
+		elem := slice.Index(i)
+		target := elem.Addr().Interface()
+		if elem.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elem.Type().Elem()))
+			target = elem.Interface()
+		}
+		if err = reply.(*Reply).decoder().Unmarshal(value, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SAdd add values and set DefaultTTL atomically
 func (r *Redis) SAdd(ctx context.Context, key string, values ...string) IReply {
 	args := stringToInterface(key, values...)
-	result := r.Do(ctx, "SADD", args...)
-	r.Expire(ctx, key, 15*60)
-	return result
+	return r.doExpire(ctx, r.defaultTTL, "SADD", args...)
 }
+
+// SAddWithExpire add values and set expire in seconds atomically.
+// expire 0 or negative keep existing expire, it was ignored before expire became atomic
 func (r *Redis) SAddWithExpire(ctx context.Context, key string, expire int, values ...string) IReply {
 	args := stringToInterface(key, values...)
-	return r.Do(ctx, "SADD", args...)
+	return r.doExpire(ctx, time.Duration(expire)*time.Second, "SADD", args...)
 }
 func (r *Redis) SAddNoExpire(ctx context.Context, key string, values ...string) IReply {
 	args := stringToInterface(key, values...)
@@ -172,15 +620,16 @@ func (r *Redis) SMembers(ctx context.Context, key string) IReply {
 func (r *Redis) SCard(ctx context.Context, key string) IReply {
 	return r.Do(ctx, "SCARD", key)
 }
+
+// HSet set fields and DefaultTTL atomically
 func (r *Redis) HSet(ctx context.Context, name string, obj interface{}) IReply {
-	result := r.Do(ctx, "HMSET", redis.Args{}.Add(name).AddFlat(obj)...)
-	r.Expire(ctx, name, 15*60)
-	return result
+	return r.doExpire(ctx, r.defaultTTL, "HMSET", redis.Args{}.Add(name).AddFlat(obj)...)
 }
+
+// HSetWithExpire set fields and expire in seconds atomically.
+// expire 0 or negative keep existing expire, hash was expired right away before expire became atomic
 func (r *Redis) HSetWithExpire(ctx context.Context, name string, expire int, obj interface{}) IReply {
-	result := r.Do(ctx, "HMSET", redis.Args{}.Add(name).AddFlat(obj)...)
-	r.Expire(ctx, name, expire)
-	return result
+	return r.doExpire(ctx, time.Duration(expire)*time.Second, "HMSET", redis.Args{}.Add(name).AddFlat(obj)...)
 }
 func (r *Redis) HSetNoExpire(ctx context.Context, name string, obj interface{}) IReply {
 	return r.Do(ctx, "HMSET", redis.Args{}.Add(name).AddFlat(obj)...)
@@ -191,10 +640,41 @@ func (r *Redis) HGet(ctx context.Context, name, key string) IReply {
 func (r *Redis) HGetAll(ctx context.Context, name string) IReply {
 	return r.Do(ctx, "HGETALL", name)
 }
+
+// HMGet get value of fields in order, reply has nil for missing field
+func (r *Redis) HMGet(ctx context.Context, name string, fields ...string) IReply {
+	return r.Do(ctx, "HMGET", redis.Args{}.Add(name).AddFlat(fields)...)
+}
 func (r *Redis) HDel(ctx context.Context, name, key string) IReply {
 	return r.Do(ctx, "HDEL", redis.Args{}.Add(name).Add(key)...)
 }
 
+// HIncrBy increment integer field, eg: counter per entity, reply is the new value
+func (r *Redis) HIncrBy(ctx context.Context, name, field string, incr int) IReply {
+	return r.Do(ctx, "HINCRBY", name, field, incr)
+}
+func (r *Redis) HExists(ctx context.Context, name, field string) (bool, error) {
+	reply, err := r.Do(ctx, "HEXISTS", name, field).Int()
+	return reply == 1, err
+}
+func (r *Redis) HKeys(ctx context.Context, name string) ([]string, error) {
+	return r.Do(ctx, "HKEYS", name).Strings()
+}
+
+// HVals value of every field, struct values can be read with UnmarshalSlice
+func (r *Redis) HVals(ctx context.Context, name string) IReply {
+	return r.Do(ctx, "HVALS", name)
+}
+func (r *Redis) HLen(ctx context.Context, name string) (int64, error) {
+	return r.Do(ctx, "HLEN", name).Int64()
+}
+
+// HSetNX set field only when it does not exist, false is returned when field already exist
+func (r *Redis) HSetNX(ctx context.Context, name, field string, value interface{}) (bool, error) {
+	reply, err := r.Do(ctx, "HSETNX", name, field, value).Int()
+	return reply == 1, err
+}
+
 func (r *Redis) ZAdd(ctx context.Context, key string, value interface{}, score int) IReply {
 	return r.Do(ctx, "ZADD", key, score, value)
 }
@@ -211,17 +691,124 @@ func (r *Redis) ZInterStore(ctx context.Context, values ...interface{}) IReply {
 	return r.Do(ctx, "ZINTERSTORE", values...)
 }
 
+func (r *Redis) LPush(ctx context.Context, key string, values ...string) IReply {
+	return r.Do(ctx, "LPUSH", stringToInterface(key, values...)...)
+}
+func (r *Redis) RPush(ctx context.Context, key string, values ...string) IReply {
+	return r.Do(ctx, "RPUSH", stringToInterface(key, values...)...)
+}
+func (r *Redis) LPop(ctx context.Context, key string) IReply {
+	return r.Do(ctx, "LPOP", key)
+}
+func (r *Redis) RPop(ctx context.Context, key string) IReply {
+	return r.Do(ctx, "RPOP", key)
+}
+func (r *Redis) LRange(ctx context.Context, key string, start, stop int) IReply {
+	return r.Do(ctx, "LRANGE", key, start, stop)
+}
+func (r *Redis) LLen(ctx context.Context, key string) IReply {
+	return r.Do(ctx, "LLEN", key)
+}
+func (r *Redis) LTrim(ctx context.Context, key string, start, stop int) IReply {
+	return r.Do(ctx, "LTRIM", key, start, stop)
+}
+func (r *Redis) LRem(ctx context.Context, key string, count int, value string) IReply {
+	return r.Do(ctx, "LREM", key, count, value)
+}
+
+// BLPop pop from the first non-empty list of keys, waiting up to timeout (rounded up to second, 0 wait until ctx
+// is done), reply is [key, value] or nil when timeout is reached
+func (r *Redis) BLPop(ctx context.Context, timeout time.Duration, keys ...string) IReply {
+	return r.blockingPop(ctx, "BLPOP", timeout, keys)
+}
+
+// BRPop pop from tail of the first non-empty list of keys, see BLPop
+func (r *Redis) BRPop(ctx context.Context, timeout time.Duration, keys ...string) IReply {
+	return r.blockingPop(ctx, "BRPOP", timeout, keys)
+}
+
+func (r *Redis) blockingPop(ctx context.Context, command string, timeout time.Duration, keys []string) IReply {
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	args := make([]interface{}, 0, len(keys)+1)
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	args = append(args, seconds)
+
+	var reply *Reply
+	if seconds == 0 {
+		reply = r.do(ctx, 0, command, args)
+	} else {
+		reply = r.doBlocking(ctx, time.Duration(seconds)*time.Second, command, args...)
+	}
+	// reply is [key, value], key is returned without KeyPrefix
+	if popped, ok := reply.result.([]interface{}); ok && len(popped) == 2 && r.prefix != "" {
+		popped[0] = strings.TrimPrefix(keyString(popped[0]), r.prefix)
+	}
+	return reply
+}
+
+// PFAdd add elements to HyperLogLog, reply is 1 when estimated cardinality changed
+func (r *Redis) PFAdd(ctx context.Context, key string, elements ...string) IReply {
+	return r.Do(ctx, "PFADD", stringToInterface(key, elements...)...)
+}
+
+// PFCount return approximate number of unique elements (0.81% standard error) of union of keys
+func (r *Redis) PFCount(ctx context.Context, keys ...string) IReply {
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+	return r.Do(ctx, "PFCOUNT", args...)
+}
+
+// PFMerge store union of HyperLogLog keys into dest, eg: weekly unique from daily keys
+func (r *Redis) PFMerge(ctx context.Context, dest string, keys ...string) IReply {
+	return r.Do(ctx, "PFMERGE", stringToInterface(dest, keys...)...)
+}
+
+// SetBit set bit at offset to value (0 or 1), reply is the previous bit
+func (r *Redis) SetBit(ctx context.Context, key string, offset int64, value int) IReply {
+	return r.Do(ctx, "SETBIT", key, offset, value)
+}
+func (r *Redis) GetBit(ctx context.Context, key string, offset int64) IReply {
+	return r.Do(ctx, "GETBIT", key, offset)
+}
+
+// BitCount return number of bits set to 1
+func (r *Redis) BitCount(ctx context.Context, key string) IReply {
+	return r.Do(ctx, "BITCOUNT", key)
+}
+
+// BitOp store result of bitwise operation (AND, OR, XOR or NOT) between keys into dest
+func (r *Redis) BitOp(ctx context.Context, op, dest string, keys ...string) IReply {
+	return r.Do(ctx, "BITOP", redis.Args{}.Add(op, dest).AddFlat(keys)...)
+}
+
 func (rp *Reply) Unmarshal(obj interface{}) error {
 	b, err := redis.Bytes(rp.result, rp.error)
 	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(b, obj)
+	err = rp.decoder().Unmarshal(b, obj)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// UnmarshalSlice unmarshal every element of multi-bulk reply (eg: MGET, LRANGE or SMEMBERS) with its codec into
+// dest pointer to slice, eg: *[]User or *[]*User where nil element (missing key of MGET) is left nil
+func (rp *Reply) UnmarshalSlice(dest interface{}) error {
+	return unmarshalValues(rp, dest, -1)
+}
+
+func (rp *Reply) decoder() Codec {
+	if rp.codec == nil {
+		return JSONCodec
+	}
+	return rp.codec
+}
 func (rp *Reply) Error() error {
 	return rp.error
 }
@@ -246,6 +833,34 @@ func (rp *Reply) Strings() ([]string, error) {
 func (rp *Reply) Bytes() ([]byte, error) {
 	return redis.Bytes(rp.result, rp.error)
 }
+func (rp *Reply) ByteSlices() ([][]byte, error) {
+	return redis.ByteSlices(rp.result, rp.error)
+}
+func (rp *Reply) Ints() ([]int, error) {
+	return redis.Ints(rp.result, rp.error)
+}
+func (rp *Reply) Int64s() ([]int64, error) {
+	return redis.Int64s(rp.result, rp.error)
+}
+func (rp *Reply) Float64s() ([]float64, error) {
+	return redis.Float64s(rp.result, rp.error)
+}
+
+// StringMap convert field value pairs, eg: HGETALL or ZRANGE WITHSCORES
+func (rp *Reply) StringMap() (map[string]string, error) {
+	return redis.StringMap(rp.result, rp.error)
+}
+func (rp *Reply) IntMap() (map[string]int, error) {
+	return redis.IntMap(rp.result, rp.error)
+}
+func (rp *Reply) Int64Map() (map[string]int64, error) {
+	return redis.Int64Map(rp.result, rp.error)
+}
+
+// Values return raw elements of multi-bulk reply, nested reply is kept as []interface{}
+func (rp *Reply) Values() ([]interface{}, error) {
+	return redis.Values(rp.result, rp.error)
+}
 func (rp *Reply) Struct(obj interface{}) error {
 	result, err := redis.Values(rp.result, rp.error)
 	if err != nil {
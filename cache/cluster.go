@@ -0,0 +1,491 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+type ClusterConfig struct {
+	// list of cluster node in host:port format used to discover slot map, one reachable node is enough
+	Addrs []string
+
+	// the rest is applied to connection to every node, see RedisConfig, database index is not supported by cluster
+	Username       string
+	Password       string
+	Timeout        int
+	MaxIdle        int
+	MaxActive      int
+	Codec          Codec
+	KeyPrefix      string
+	DefaultTTL     time.Duration
+	Name           string
+	Hooks          []Hook
+	EnableMetrics  bool
+	EnableTracing  bool
+	HashTracedKeys bool
+	Retry          RetryConfig
+}
+
+type cluster struct {
+	config  RedisConfig
+	timeout time.Duration
+	seeds   []string
+
+	mu    sync.RWMutex
+	slots [clusterSlots]string
+	pools map[string]*redis.Pool
+
+	refreshing int32
+}
+
+const (
+	clusterSlots        = 16384
+	maxClusterRedirects = 5
+)
+
+const ErrorFailedLoadSlots = "Failed to load redis cluster slots from %v. Error: %s"
+
+// ErrCrossSlot multi-key command keys must hash to the same slot in cluster mode, use hash tag to force it,
+// eg: {user:1}:profile and {user:1}:orders both hash only "user:1"
+var ErrCrossSlot = errors.New("keys in multi-key command do not hash to the same slot, use hash tag eg: {user:1}:profile")
+
+// ConnectRedisCluster create redis ICache which route every command to the node owning its key slot,
+// MOVED redirect refresh the slot map and ASK redirect is followed once without updating it
+func ConnectRedisCluster(config ClusterConfig) (ICache, error) {
+	timeout := time.Duration(config.Timeout) * time.Second
+	c := &cluster{
+		config: RedisConfig{
+			Username:  config.Username,
+			Password:  config.Password,
+			Timeout:   config.Timeout,
+			MaxIdle:   config.MaxIdle,
+			MaxActive: config.MaxActive,
+		},
+		timeout: timeout,
+		seeds:   append([]string(nil), config.Addrs...),
+		pools:   map[string]*redis.Pool{},
+	}
+	if err := c.refresh(); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	r := &Redis{connection: strings.Join(config.Addrs, ","), timeout: timeout, codec: config.Codec, prefix: config.KeyPrefix,
+		defaultTTL: defaultTTL(config.DefaultTTL),
+		hooks: commandHooks(RedisConfig{Name: config.Name, Hooks: config.Hooks, EnableMetrics: config.EnableMetrics,
+			EnableTracing: config.EnableTracing, HashTracedKeys: config.HashTracedKeys}), retry: config.Retry, cluster: c}
+	if err := r.Ping(); err != nil {
+		c.close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// ClusterSlot return cluster hash slot of key, only content of the first non-empty {hash tag} is hashed when present
+func ClusterSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % clusterSlots)
+}
+
+// crc16 is CRC16-CCITT (XMODEM) used by redis cluster key hashing
+func crc16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// do run command on node owning its keys and follow MOVED and ASK redirects
+func (c *cluster) do(ctx context.Context, timeout time.Duration, command string, args []interface{}) (interface{}, error) {
+	slot, err := commandSlot(command, args)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := c.nodeAddr(slot)
+	asking := false
+	for i := 0; ; i++ {
+		pooled, err := c.pool(addr).GetContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conn := pooled.(redis.ConnWithTimeout)
+		if asking {
+			if _, err = conn.DoWithTimeout(timeout, "ASKING"); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		result, err := conn.DoWithTimeout(timeout, command, args...)
+		conn.Close()
+
+		redirect, target, ok := parseRedirect(err)
+		if !ok || i == maxClusterRedirects {
+			return result, err
+		}
+		if redirect == "MOVED" {
+			c.setSlot(slot, target)
+			c.refreshAsync()
+		}
+		addr, asking = target, redirect == "ASK"
+	}
+}
+
+// pipeline run commands as one pipeline per node owning their slot, command redirected while slot is migrating
+// is retried alone with do
+func (c *cluster) pipeline(ctx context.Context, timeout time.Duration, commands []pipelineCommand) ([]IReply, error) {
+	replies := make([]IReply, len(commands))
+	nodes := map[string][]int{}
+	var order []string
+	for i, command := range commands {
+		slot, err := commandSlot(command.name, command.args)
+		if err != nil {
+			replies[i] = &Reply{result: nil, error: err}
+			continue
+		}
+		addr := c.nodeAddr(slot)
+		if _, ok := nodes[addr]; !ok {
+			order = append(order, addr)
+		}
+		nodes[addr] = append(nodes[addr], i)
+	}
+
+	for _, addr := range order {
+		indexes := nodes[addr]
+		batch := make([]pipelineCommand, len(indexes))
+		for i, index := range indexes {
+			batch[i] = commands[index]
+		}
+
+		pooled, err := c.pool(addr).GetContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conn := pooled.(redis.ConnWithTimeout)
+		batchReplies, err := runPipeline(conn, timeout, batch)
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for i, index := range indexes {
+			if _, _, ok := parseRedirect(batchReplies[i].Error()); ok {
+				result, err := c.do(ctx, timeout, batch[i].name, batch[i].args)
+				batchReplies[i] = &Reply{result: result, error: err}
+			}
+			replies[index] = batchReplies[i]
+		}
+	}
+	return replies, nil
+}
+
+// scanMasters return fetch running SCAN on every master one after another
+func (c *cluster) scanMasters(timeout time.Duration, args []interface{}) scanFetch {
+	masters := c.masters()
+	scans := make([]nodeScan, len(masters))
+	for i, addr := range masters {
+		pool := c.pool(addr)
+		scans[i] = func(ctx context.Context, cursor string) (string, []string, error) {
+			timeout, err := commandTimeout(ctx, timeout)
+			if err != nil {
+				return "", nil, err
+			}
+			conn, err := pool.GetContext(ctx)
+			if err != nil {
+				return "", nil, err
+			}
+			defer conn.Close()
+			result, err := conn.(redis.ConnWithTimeout).DoWithTimeout(timeout, "SCAN", append([]interface{}{cursor}, args...)...)
+			return parseScan(&Reply{result: result, error: err})
+		}
+	}
+	return scanNodes(scans)
+}
+
+// masters return address of every node owning slot
+func (c *cluster) masters() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	seen := map[string]bool{}
+	var masters []string
+	for _, addr := range c.slots {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			masters = append(masters, addr)
+		}
+	}
+	return masters
+}
+
+// nodeAddr return node owning slot, keyless command and unknown slot go to any known node
+func (c *cluster) nodeAddr(slot int) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if slot >= 0 && c.slots[slot] != "" {
+		return c.slots[slot]
+	}
+	for _, addr := range c.slots {
+		if addr != "" {
+			return addr
+		}
+	}
+	return c.seeds[0]
+}
+
+func (c *cluster) setSlot(slot int, addr string) {
+	if slot < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.slots[slot] = addr
+	c.mu.Unlock()
+}
+
+func (c *cluster) pool(addr string) *redis.Pool {
+	c.mu.RLock()
+	pool, ok := c.pools[addr]
+	c.mu.RUnlock()
+	if ok {
+		return pool
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if pool, ok = c.pools[addr]; !ok {
+		config := c.config
+		config.Connection = addr
+		pool = newRedisPool(config, func() (redis.Conn, error) {
+			return dialRedis(config, c.timeout)
+		})
+		c.pools[addr] = pool
+	}
+	return pool
+}
+
+func (c *cluster) refreshAsync() {
+	if !atomic.CompareAndSwapInt32(&c.refreshing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&c.refreshing, 0)
+		c.refresh()
+	}()
+}
+
+// refresh load slot map with CLUSTER SLOTS from known nodes then seeds
+func (c *cluster) refresh() error {
+	c.mu.RLock()
+	addrs := make([]string, 0, len(c.pools)+len(c.seeds))
+	for addr := range c.pools {
+		addrs = append(addrs, addr)
+	}
+	c.mu.RUnlock()
+	addrs = append(addrs, c.seeds...)
+
+	var err error
+	for _, addr := range addrs {
+		var slots [clusterSlots]string
+		if slots, err = c.loadSlots(addr); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		c.slots = slots
+		c.mu.Unlock()
+		return nil
+	}
+	if err == nil {
+		err = errors.New("no cluster node configured")
+	}
+	return fmt.Errorf(ErrorFailedLoadSlots, c.seeds, err)
+}
+
+func (c *cluster) loadSlots(addr string) ([clusterSlots]string, error) {
+	var slots [clusterSlots]string
+
+	conn := c.pool(addr).Get().(redis.ConnWithTimeout)
+	defer conn.Close()
+	ranges, err := redis.Values(conn.DoWithTimeout(c.timeout, "CLUSTER", "SLOTS"))
+	if err != nil {
+		return slots, err
+	}
+
+	for _, r := range ranges {
+		// [start, end, [host, port, id], replicas...]
+		fields, err := redis.Values(r, nil)
+		if err != nil || len(fields) < 3 {
+			return slots, fmt.Errorf("unexpected cluster slots reply %v", r)
+		}
+		start, _ := redis.Int(fields[0], nil)
+		end, _ := redis.Int(fields[1], nil)
+		node, err := redis.Values(fields[2], nil)
+		if err != nil || len(node) < 2 {
+			return slots, fmt.Errorf("unexpected cluster slots reply %v", r)
+		}
+		host, _ := redis.String(node[0], nil)
+		port, _ := redis.Int(node[1], nil)
+		if host == "" {
+			// empty host means the node we asked
+			host, _, _ = net.SplitHostPort(addr)
+		}
+		for slot := start; slot <= end && slot < clusterSlots; slot++ {
+			slots[slot] = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+	}
+	return slots, nil
+}
+
+func (c *cluster) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pool := range c.pools {
+		pool.Close()
+	}
+}
+
+// parseRedirect parse "MOVED 3999 127.0.0.1:6381" and "ASK 3999 127.0.0.1:6381" error
+func parseRedirect(err error) (string, string, bool) {
+	redisErr, ok := err.(redis.Error)
+	if !ok {
+		return "", "", false
+	}
+	parts := strings.Fields(string(redisErr))
+	if len(parts) != 3 || (parts[0] != "MOVED" && parts[0] != "ASK") {
+		return "", "", false
+	}
+	return parts[0], parts[2], true
+}
+
+// commandSlot return slot of command keys, -1 for keyless command,
+// ErrCrossSlot is returned when keys of multi-key command hash to different slots
+func commandSlot(command string, args []interface{}) (int, error) {
+	keys := commandKeys(strings.ToUpper(command), args)
+	slot := -1
+	for _, key := range keys {
+		s := ClusterSlot(keyString(key))
+		if slot >= 0 && s != slot {
+			return 0, ErrCrossSlot
+		}
+		slot = s
+	}
+	return slot, nil
+}
+
+func keyString(key interface{}) string {
+	if b, ok := key.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(key)
+}
+
+func commandKeys(command string, args []interface{}) []interface{} {
+	indexes := commandKeyIndexes(command, args)
+	keys := make([]interface{}, len(indexes))
+	for i, index := range indexes {
+		keys[i] = args[index]
+	}
+	return keys
+}
+
+// commandKeyIndexes return index of every key in args of upper case command, most command has key as first argument
+func commandKeyIndexes(command string, args []interface{}) []int {
+	switch command {
+	case "PING", "INFO", "TIME", "DBSIZE", "FLUSHALL", "FLUSHDB", "SCRIPT", "CLUSTER", "SCAN", "RANDOMKEY",
+		"CONFIG", "ROLE", "SENTINEL", "CLIENT", "ECHO", "AUTH", "SELECT", "ASKING", "MULTI", "EXEC", "DISCARD",
+		"UNWATCH", "PUBLISH", "MODULE", "WAIT", "COMMAND", "SLOWLOG", "LASTSAVE", "PUBSUB", "FUNCTION", "SAVE",
+		"BGSAVE", "BGREWRITEAOF", "LATENCY", "ACL", "HELLO", "READONLY", "READWRITE", "SWAPDB", "SUBSCRIBE",
+		"PSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE":
+		return nil
+	case "MGET", "DEL", "UNLINK", "EXISTS", "TOUCH", "WATCH", "SINTER", "SUNION", "SDIFF",
+		"SINTERSTORE", "SUNIONSTORE", "SDIFFSTORE", "PFCOUNT", "PFMERGE":
+		return indexRange(0, len(args), 1)
+	case "MSET", "MSETNX":
+		return indexRange(0, len(args), 2)
+	case "RENAME", "RENAMENX", "SMOVE", "RPOPLPUSH", "LMOVE", "COPY":
+		if len(args) >= 2 {
+			return indexRange(0, 2, 1)
+		}
+	case "BLPOP", "BRPOP", "BZPOPMIN", "BZPOPMAX":
+		if len(args) >= 2 {
+			// last argument is timeout
+			return indexRange(0, len(args)-1, 1)
+		}
+	case "BITOP":
+		// operation destkey key [key ...]
+		return indexRange(1, len(args), 1)
+	case "XGROUP", "XINFO", "OBJECT", "MEMORY":
+		// subcommand key ...
+		if len(args) >= 2 {
+			return []int{1}
+		}
+		return nil
+	case "XREAD", "XREADGROUP":
+		// ... STREAMS key [key ...] id [id ...]
+		for i, arg := range args {
+			if strings.EqualFold(keyString(arg), "STREAMS") {
+				return indexRange(i+1, i+1+(len(args)-i-1)/2, 1)
+			}
+		}
+		return nil
+	case "ZINTERSTORE", "ZUNIONSTORE", "ZDIFFSTORE":
+		// destination numkeys key [key ...]
+		return numKeys(args, 1, true)
+	case "ZUNION", "ZINTER", "ZDIFF", "ZINTERCARD", "SINTERCARD", "LMPOP", "ZMPOP":
+		// numkeys key [key ...]
+		return numKeys(args, 0, false)
+	case "EVAL", "EVALSHA", "EVAL_RO", "EVALSHA_RO", "FCALL", "FCALL_RO", "BLMPOP", "BZMPOP":
+		// script, function or timeout, numkeys key [key ...]
+		return numKeys(args, 1, false)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return []int{0}
+}
+
+func indexRange(start, end, step int) []int {
+	var indexes []int
+	for i := start; i < end; i += step {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// numKeys return index of keys counted by numkeys argument at index, withFirst include key before numkeys,
+// eg: destination
+func numKeys(args []interface{}, index int, withFirst bool) []int {
+	var indexes []int
+	if withFirst && len(args) > 0 {
+		indexes = append(indexes, 0)
+	}
+	if len(args) <= index {
+		return indexes
+	}
+	n, err := strconv.Atoi(fmt.Sprint(args[index]))
+	if err != nil || n < 0 || len(args) < index+1+n {
+		return indexes
+	}
+	return append(indexes, indexRange(index+1, index+1+n, 1)...)
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encode value of struct commands (SetStruct, MSetStruct...) and decode it in Reply.Unmarshal and MGetStruct,
+// value must be read with the codec which wrote it
+type Codec interface {
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte, value interface{}) error
+}
+
+var (
+	// JSONCodec is the default codec
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec only support Go reader, type must be gob.Register-ed when stored as interface
+	GobCodec Codec = gobCodec{}
+	// MsgpackCodec is more compact and faster than JSON, struct use msgpack tag and fallback to field name
+	MsgpackCodec Codec = msgpackCodec{}
+	// ProtobufCodec only accept proto.Message value
+	ProtobufCodec Codec = protobufCodec{}
+)
+
+type codecKey struct{}
+
+// WithCodec override client codec for command run with returned ctx, eg: store one large struct with msgpack
+func WithCodec(ctx context.Context, codec Codec) context.Context {
+	return context.WithValue(ctx, codecKey{}, codec)
+}
+
+// codecFrom return codec of ctx, client codec or JSONCodec
+func codecFrom(ctx context.Context, codec Codec) Codec {
+	if c, ok := ctx.Value(codecKey{}).(Codec); ok && c != nil {
+		return c
+	}
+	if codec != nil {
+		return codec
+	}
+	return JSONCodec
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec) Unmarshal(data []byte, value interface{}) error {
+	return json.Unmarshal(data, value)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, value interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(value interface{}) ([]byte, error) {
+	return msgpack.Marshal(value)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, value interface{}) error {
+	return msgpack.Unmarshal(data, value)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(value interface{}) ([]byte, error) {
+	message, ok := value.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec require proto.Message, got %T", value)
+	}
+	return proto.Marshal(message)
+}
+
+func (protobufCodec) Unmarshal(data []byte, value interface{}) error {
+	message, ok := value.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf codec require proto.Message, got %T", value)
+	}
+	return proto.Unmarshal(data, message)
+}
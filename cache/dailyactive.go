@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// DailyActive track which user id was active on a day with one bitmap per day, user id is the bit offset
+// so it should be dense sequence number, eg: 10 million users take about 1.2MB per day
+type DailyActive struct {
+	cache  ICache
+	name   string
+	expire int
+}
+
+const dailyActiveLayout = "2006-01-02"
+
+// NewDailyActive create tracker storing bitmap under name:YYYY-MM-DD, expire (in second) is set on every mark,
+// 0 keep bitmap forever
+func NewDailyActive(cache ICache, name string, expire int) *DailyActive {
+	return &DailyActive{cache: cache, name: name, expire: expire}
+}
+
+// Key return bitmap key of date, date is formatted in its own location
+func (d *DailyActive) Key(date time.Time) string {
+	return d.name + ":" + date.Format(dailyActiveLayout)
+}
+
+// Mark flag user as active on date
+func (d *DailyActive) Mark(ctx context.Context, userID int64, date time.Time) error {
+	key := d.Key(date)
+	if err := d.cache.SetBit(ctx, key, userID, 1).Error(); err != nil {
+		return err
+	}
+	if d.expire > 0 {
+		return d.cache.Expire(ctx, key, d.expire).Error()
+	}
+	return nil
+}
+
+func (d *DailyActive) IsActive(ctx context.Context, userID int64, date time.Time) (bool, error) {
+	return d.cache.GetBit(ctx, d.Key(date), userID).Bool()
+}
+
+// Count return number of active users on date
+func (d *DailyActive) Count(ctx context.Context, date time.Time) (int64, error) {
+	return d.cache.BitCount(ctx, d.Key(date)).Int64()
+}
+
+// CountRange return number of users active on at least one day between from and to inclusive,
+// days are merged with BITOP OR into short-lived key
+func (d *DailyActive) CountRange(ctx context.Context, from, to time.Time) (int64, error) {
+	var keys []string
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		keys = append(keys, d.Key(day))
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	dest := d.name + ":" + from.Format(dailyActiveLayout) + ":" + to.Format(dailyActiveLayout)
+	if err := d.cache.BitOp(ctx, "OR", dest, keys...).Error(); err != nil {
+		return 0, err
+	}
+	defer d.cache.Del(context.Background(), dest)
+	return d.cache.BitCount(ctx, dest).Int64()
+}
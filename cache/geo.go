@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+type GeoLocation struct {
+	Member    string
+	Longitude float64
+	Latitude  float64
+	// distance from search center in search unit, only set by GeoSearch
+	Distance float64
+}
+
+// GeoSearchQuery search members within Radius around Member or Longitude and Latitude when Member is empty
+type GeoSearchQuery struct {
+	Member    string
+	Longitude float64
+	Latitude  float64
+	Radius    float64
+	// m, km, mi or ft, by default m
+	Unit string
+	// max result, by default unlimited
+	Count int
+	// sort by distance, ASC or DESC, by default unsorted
+	Sort string
+}
+
+// GeoAdd add or update location of members, reply is number of new members
+func (r *Redis) GeoAdd(ctx context.Context, key string, locations ...GeoLocation) IReply {
+	args := redis.Args{}.Add(key)
+	for _, location := range locations {
+		args = args.Add(location.Longitude, location.Latitude, location.Member)
+	}
+	return r.Do(ctx, "GEOADD", args...)
+}
+
+// GeoDist return distance between two members in unit (m, km, mi or ft, by default m),
+// reply is nil when one of them does not exist
+func (r *Redis) GeoDist(ctx context.Context, key, member1, member2, unit string) IReply {
+	return r.Do(ctx, "GEODIST", key, member1, member2, geoUnit(unit))
+}
+
+// GeoSearch return members within radius with coordinate and distance, GEOSEARCH is used on redis 6.2 and
+// GEORADIUS or GEORADIUSBYMEMBER on older server
+func (r *Redis) GeoSearch(ctx context.Context, key string, query GeoSearchQuery) ([]GeoLocation, error) {
+	var options redis.Args
+	if query.Sort != "" {
+		options = options.Add(strings.ToUpper(query.Sort))
+	}
+	if query.Count > 0 {
+		options = options.Add("COUNT", query.Count)
+	}
+	options = options.Add("WITHCOORD", "WITHDIST")
+
+	args := redis.Args{}.Add(key)
+	if query.Member != "" {
+		args = args.Add("FROMMEMBER", query.Member)
+	} else {
+		args = args.Add("FROMLONLAT", query.Longitude, query.Latitude)
+	}
+	args = args.Add("BYRADIUS", query.Radius, geoUnit(query.Unit)).Add(options...)
+
+	reply := r.do(ctx, r.timeout, "GEOSEARCH", args)
+	result, err := redis.Values(reply.result, reply.error)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+		command, args := "GEORADIUSBYMEMBER", redis.Args{}.Add(key, query.Member)
+		if query.Member == "" {
+			command, args = "GEORADIUS", redis.Args{}.Add(key, query.Longitude, query.Latitude)
+		}
+		args = args.Add(query.Radius, geoUnit(query.Unit)).Add(options...)
+		reply = r.do(ctx, r.timeout, command, args)
+		result, err = redis.Values(reply.result, reply.error)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseGeoLocations(result)
+}
+
+func geoUnit(unit string) string {
+	if unit == "" {
+		return "m"
+	}
+	return unit
+}
+
+// parseGeoLocations parse [[member, distance, [longitude, latitude]]...] of WITHDIST WITHCOORD
+func parseGeoLocations(result []interface{}) ([]GeoLocation, error) {
+	locations := make([]GeoLocation, 0, len(result))
+	for _, item := range result {
+		fields, err := redis.Values(item, nil)
+		if err != nil || len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected geo reply %v", item)
+		}
+		var location GeoLocation
+		if location.Member, err = redis.String(fields[0], nil); err != nil {
+			return nil, err
+		}
+		if location.Distance, err = redis.Float64(fields[1], nil); err != nil {
+			return nil, err
+		}
+		coord, err := redis.Float64s(fields[2], nil)
+		if err != nil || len(coord) != 2 {
+			return nil, fmt.Errorf("unexpected geo coordinate %v", fields[2])
+		}
+		location.Longitude, location.Latitude = coord[0], coord[1]
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// Loader load value of GetOrSet on cache miss
+type Loader func(ctx context.Context) (interface{}, error)
+
+// GetOrSet unmarshal cached value of key into dest, on miss value of loader is stored with ttl (zero ttl never
+// expire) and unmarshaled into dest. concurrent miss of the same key wait for single loader, it run with ctx of the
+// first caller. loader is still called when cache fail and failed write back is only logged
+func (r *Redis) GetOrSet(ctx context.Context, key string, ttl time.Duration, dest interface{}, loader Loader) error {
+	return getOrSet(ctx, r, &r.loads, codecFrom(ctx, r.codec), key, ttl, dest, loader)
+}
+
+// GetOrSet see Redis.GetOrSet
+func (m *Memcached) GetOrSet(ctx context.Context, key string, ttl time.Duration, dest interface{}, loader Loader) error {
+	return getOrSet(ctx, m, &m.loads, codecFrom(ctx, m.codec), key, ttl, dest, loader)
+}
+
+func getOrSet(ctx context.Context, c ICache, loads *singleflight.Group, codec Codec, key string, ttl time.Duration,
+	dest interface{}, loader Loader) error {
+	reply := c.Get(ctx, key)
+	err := reply.Error()
+	if err == nil {
+		if err = reply.Unmarshal(dest); err == nil {
+			return nil
+		}
+	}
+	if err != ErrorNil {
+		log.WithFields(log.Fields{"key": key, "error": err.Error()}).Error("Failed to get cached value, loading it")
+	}
+
+	data, err, _ := loads.Do(key, func() (interface{}, error) {
+		value, err := loader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := codec.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.SetTTL(ctx, key, data, ttl).Error(); err != nil {
+			log.WithFields(log.Fields{"key": key, "error": err.Error()}).Error("Failed to store loaded value")
+		}
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(data.([]byte), dest)
+}
@@ -0,0 +1,390 @@
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// GoRedisConfig connect redis with go-redis driver instead of redigo, MasterName select sentinel, more than one
+// address or Cluster select cluster and single redis otherwise
+type GoRedisConfig struct {
+	// list of redis, sentinel or cluster node in host:port format
+	Addrs []string
+	// name of master monitored by sentinel, Addrs is list of sentinel when set
+	MasterName string
+	// use cluster with single seed address, by default cluster is used when there is more than one address
+	Cluster bool
+
+	// redis 6 ACL user, by default password is sent as default user
+	Username string
+	Password string
+	// password of sentinel, by default sentinel does not require auth
+	SentinelPassword string
+	// database index, not supported by cluster, by default 0
+	DB int
+	// connect and command timeout (in second), by default no command timeout
+	Timeout   int
+	MaxIdle   int
+	MaxActive int
+	// by default plain TCP
+	TLS *tls.Config
+
+	// see RedisConfig
+	Codec          Codec
+	KeyPrefix      string
+	DefaultTTL     time.Duration
+	Name           string
+	Hooks          []Hook
+	EnableMetrics  bool
+	EnableTracing  bool
+	HashTracedKeys bool
+	Retry          RetryConfig
+}
+
+// ConnectGoRedis create redis ICache backed by go-redis, every command behave like ConnectRedis
+func ConnectGoRedis(config GoRedisConfig) (ICache, error) {
+	timeout := time.Duration(config.Timeout) * time.Second
+	// command timeout is applied with ctx, see Redis.do
+	var client goredis.UniversalClient
+	switch {
+	case config.MasterName != "":
+		client = goredis.NewFailoverClient(&goredis.FailoverOptions{
+			MasterName:            config.MasterName,
+			SentinelAddrs:         config.Addrs,
+			SentinelPassword:      config.SentinelPassword,
+			Username:              config.Username,
+			Password:              config.Password,
+			DB:                    config.DB,
+			Protocol:              2,
+			MaxRetries:            -1,
+			DialTimeout:           timeout,
+			ReadTimeout:           -1,
+			WriteTimeout:          -1,
+			ContextTimeoutEnabled: true,
+			PoolSize:              config.MaxActive,
+			MaxIdleConns:          config.MaxIdle,
+			TLSConfig:             config.TLS,
+		})
+	case config.Cluster || len(config.Addrs) > 1:
+		client = goredis.NewClusterClient(&goredis.ClusterOptions{
+			Addrs:                 config.Addrs,
+			Username:              config.Username,
+			Password:              config.Password,
+			Protocol:              2,
+			MaxRetries:            -1,
+			DialTimeout:           timeout,
+			ReadTimeout:           -1,
+			WriteTimeout:          -1,
+			ContextTimeoutEnabled: true,
+			PoolSize:              config.MaxActive,
+			MaxIdleConns:          config.MaxIdle,
+			TLSConfig:             config.TLS,
+		})
+	default:
+		var addr string
+		if len(config.Addrs) > 0 {
+			addr = config.Addrs[0]
+		}
+		client = goredis.NewClient(&goredis.Options{
+			Addr:                  addr,
+			Username:              config.Username,
+			Password:              config.Password,
+			DB:                    config.DB,
+			Protocol:              2,
+			MaxRetries:            -1,
+			DialTimeout:           timeout,
+			ReadTimeout:           -1,
+			WriteTimeout:          -1,
+			ContextTimeoutEnabled: true,
+			PoolSize:              config.MaxActive,
+			MaxIdleConns:          config.MaxIdle,
+			TLSConfig:             config.TLS,
+		})
+	}
+
+	r := &Redis{connection: strings.Join(config.Addrs, ","), timeout: timeout, db: config.DB, codec: config.Codec,
+		prefix: config.KeyPrefix, defaultTTL: defaultTTL(config.DefaultTTL), retry: config.Retry, client: client}
+	r.hooks = commandHooks(RedisConfig{Name: config.Name, Hooks: config.Hooks, EnableMetrics: config.EnableMetrics,
+		EnableTracing: config.EnableTracing, HashTracedKeys: config.HashTracedKeys})
+	if err := r.Ping(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// clustered command is routed by key slot, with redigo cluster or go-redis cluster client
+func (r *Redis) clustered() bool {
+	if r.cluster != nil {
+		return true
+	}
+	_, ok := r.client.(*goredis.ClusterClient)
+	return ok
+}
+
+// goRedisDo run command with go-redis, zero timeout means no timeout
+func (r *Redis) goRedisDo(ctx context.Context, timeout time.Duration, command string, args []interface{}) (interface{}, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return fromGoRedis(r.client.Do(ctx, append([]interface{}{command}, args...)...).Result())
+}
+
+// goRedisPipeline send commands with go-redis pipeline, it is split per node in cluster mode
+func (r *Redis) goRedisPipeline(ctx context.Context, timeout time.Duration, commands []pipelineCommand) ([]IReply, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*goredis.Cmd, len(commands))
+	for i, command := range commands {
+		cmds[i] = pipe.Do(ctx, append([]interface{}{command.name}, command.args...)...)
+	}
+	pipe.Exec(ctx)
+
+	replies := make([]IReply, len(commands))
+	for i, cmd := range cmds {
+		result, err := fromGoRedis(cmd.Result())
+		if _, ok := err.(redis.Error); err != nil && !ok {
+			return nil, err
+		}
+		replies[i] = &Reply{result: result, error: err}
+	}
+	return replies, nil
+}
+
+// goRedisTxConn open dedicated connection of transaction, to master of key in cluster mode
+func (r *Redis) goRedisTxConn(ctx context.Context, key string) (redis.ConnWithTimeout, error) {
+	switch client := r.client.(type) {
+	case *goredis.Client:
+		return &goRedisConn{ctx: ctx, conn: client.Conn()}, nil
+	case *goredis.ClusterClient:
+		master, err := client.MasterForKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return &goRedisConn{ctx: ctx, conn: master.Conn()}, nil
+	}
+	return nil, fmt.Errorf("unsupported go-redis client %T", r.client)
+}
+
+// goRedisMasters return client of every master, the client itself when it is not cluster
+func (r *Redis) goRedisMasters(ctx context.Context) ([]*goredis.Client, error) {
+	switch client := r.client.(type) {
+	case *goredis.Client:
+		return []*goredis.Client{client}, nil
+	case *goredis.ClusterClient:
+		var mu sync.Mutex
+		var masters []*goredis.Client
+		err := client.ForEachMaster(ctx, func(ctx context.Context, master *goredis.Client) error {
+			mu.Lock()
+			masters = append(masters, master)
+			mu.Unlock()
+			return nil
+		})
+		return masters, err
+	}
+	return nil, fmt.Errorf("unsupported go-redis client %T", r.client)
+}
+
+// goRedisNodeDo run command on one node of go-redis cluster with configured timeout
+func (r *Redis) goRedisNodeDo(ctx context.Context, node *goredis.Client, args []interface{}) (interface{}, error) {
+	timeout, err := commandTimeout(ctx, r.timeout)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return fromGoRedis(node.Do(ctx, args...).Result())
+}
+
+// goRedisScanMasters return fetch running SCAN on every master of go-redis cluster one after another
+func (r *Redis) goRedisScanMasters(ctx context.Context, args []interface{}) (scanFetch, error) {
+	masters, err := r.goRedisMasters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scans := make([]nodeScan, len(masters))
+	for i, master := range masters {
+		master := master
+		scans[i] = func(ctx context.Context, cursor string) (string, []string, error) {
+			result, err := r.goRedisNodeDo(ctx, master, append([]interface{}{"SCAN", cursor}, args...))
+			return parseScan(&Reply{result: result, error: err})
+		}
+	}
+	return scanNodes(scans), nil
+}
+
+// goRedisSubscribe wait until every channel of pubsub is confirmed then pass message to handle until ctx is done,
+// go-redis resubscribe after reconnect
+func goRedisSubscribe(ctx context.Context, pubsub *goredis.PubSub, channels int, timeout time.Duration) (func(handle func(channel string, data []byte)), error) {
+	for confirmed := 0; confirmed < channels; {
+		message, err := pubsub.ReceiveTimeout(ctx, timeout)
+		if err != nil {
+			pubsub.Close()
+			return nil, err
+		}
+		if _, ok := message.(*goredis.Subscription); ok {
+			confirmed++
+		}
+	}
+
+	return func(handle func(channel string, data []byte)) {
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				handle(message.Channel, []byte(message.Payload))
+			}
+		}
+	}, nil
+}
+
+// fromGoRedis convert go-redis result to the one of redigo so Reply behave the same for both driver,
+// bulk string become []byte, nil reply is not an error and server error become redis.Error
+func fromGoRedis(result interface{}, err error) (interface{}, error) {
+	if err == goredis.Nil {
+		return nil, nil
+	}
+	var redisErr goredis.Error
+	if errors.As(err, &redisErr) {
+		return nil, redis.Error(err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fromGoRedisValue(result), nil
+}
+
+func fromGoRedisValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return []byte(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = fromGoRedisValue(v[i])
+		}
+		return v
+	case goredis.Error:
+		return redis.Error(v.Error())
+	}
+	return value
+}
+
+// goRedisConn adapt dedicated go-redis connection to redigo connection used by transaction
+type goRedisConn struct {
+	ctx     context.Context
+	conn    *goredis.Conn
+	pending [][]interface{}
+	replies []*goredis.Cmd
+}
+
+func (c *goRedisConn) Send(command string, args ...interface{}) error {
+	c.pending = append(c.pending, append([]interface{}{command}, args...))
+	return nil
+}
+
+func (c *goRedisConn) Flush() error {
+	_, err := c.run(0)
+	return err
+}
+
+func (c *goRedisConn) Receive() (interface{}, error) {
+	return c.ReceiveWithTimeout(0)
+}
+
+func (c *goRedisConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	if len(c.replies) == 0 {
+		return nil, errors.New("no pending reply")
+	}
+	cmd := c.replies[0]
+	c.replies = c.replies[1:]
+	return fromGoRedis(cmd.Result())
+}
+
+func (c *goRedisConn) Do(command string, args ...interface{}) (interface{}, error) {
+	return c.DoWithTimeout(0, command, args...)
+}
+
+// DoWithTimeout send pending commands with command in one pipeline, like redigo reply of command is returned with
+// the first server error of pending commands
+func (c *goRedisConn) DoWithTimeout(timeout time.Duration, command string, args ...interface{}) (interface{}, error) {
+	c.Send(command, args...)
+	cmds, err := c.run(timeout)
+	if err != nil {
+		return nil, err
+	}
+	c.replies = nil
+
+	var pendingErr error
+	for _, cmd := range cmds[:len(cmds)-1] {
+		if _, err := fromGoRedis(cmd.Result()); err != nil && pendingErr == nil {
+			pendingErr = err
+		}
+	}
+	result, err := fromGoRedis(cmds[len(cmds)-1].Result())
+	if err != nil {
+		return nil, err
+	}
+	return result, pendingErr
+}
+
+// run send pending commands, reply is kept for Receive and connection error is returned
+func (c *goRedisConn) run(timeout time.Duration) ([]*goredis.Cmd, error) {
+	ctx := c.ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmds := make([]*goredis.Cmd, len(c.pending))
+	c.conn.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for i, args := range c.pending {
+			cmds[i] = pipe.Do(ctx, args...)
+		}
+		return nil
+	})
+	c.pending = nil
+	for _, cmd := range cmds {
+		if _, err := fromGoRedis(cmd.Result()); err != nil {
+			if _, ok := err.(redis.Error); !ok {
+				return nil, err
+			}
+		}
+	}
+	c.replies = append(c.replies, cmds...)
+	return cmds, nil
+}
+
+func (c *goRedisConn) Err() error {
+	return nil
+}
+
+// Close release WATCH like redigo pool before connection is returned to go-redis pool
+func (c *goRedisConn) Close() error {
+	c.conn.Process(context.Background(), goredis.NewCmd(context.Background(), "UNWATCH"))
+	return c.conn.Close()
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// HGetAs read only fields of struct T named by redis struct tag with HMGET instead of HGETALL,
+// eg: HGetAs[UserName](ctx, c, "user:1") with UserName{Name string `redis:"name"`}. missing field is left zero value
+// and ErrorNil is returned when none of them exist
+func HGetAs[T any](ctx context.Context, c ICache, name string) (T, error) {
+	var value T
+	v := reflect.ValueOf(&value).Elem()
+	if v.Kind() != reflect.Struct {
+		return value, fmt.Errorf("HGetAs require struct, got %T", value)
+	}
+	var fields []string
+	hashFields(v, func(field string, _ reflect.Value) {
+		fields = append(fields, field)
+	})
+	if len(fields) == 0 {
+		return value, fmt.Errorf("%T has no exported field", value)
+	}
+
+	values, err := c.HMGet(ctx, name, fields...).Values()
+	if err != nil {
+		return value, err
+	}
+	pairs := make([]interface{}, 0, 2*len(values))
+	for i, v := range values {
+		if v != nil && i < len(fields) {
+			pairs = append(pairs, []byte(fields[i]), v)
+		}
+	}
+	if len(pairs) == 0 {
+		return value, ErrorNil
+	}
+	return value, redis.ScanStruct(pairs, &value)
+}
+
+// HSetField set only fields of struct obj named by redis struct tag, zero value is written too unlike HSet with
+// omitempty, eg: HSetField(ctx, c, "user:1", user, "email"). expire of hash is kept
+func HSetField(ctx context.Context, c ICache, name string, obj interface{}, fields ...string) IReply {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return &Reply{result: nil, error: fmt.Errorf("HSetField require struct, got %T", obj)}
+	}
+	wanted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		wanted[field] = true
+	}
+
+	args := redis.Args{}.Add(name)
+	hashFields(v, func(field string, value reflect.Value) {
+		if wanted[field] {
+			args = args.Add(field, value.Interface())
+			delete(wanted, field)
+		}
+	})
+	if len(wanted) > 0 {
+		missing := make([]string, 0, len(wanted))
+		for field := range wanted {
+			missing = append(missing, field)
+		}
+		return &Reply{result: nil, error: fmt.Errorf("%T has no redis field %s", obj, strings.Join(missing, ", "))}
+	}
+	if len(args) == 1 {
+		return &Reply{result: nil, error: fmt.Errorf("no field to set")}
+	}
+	return c.Do(ctx, "HSET", args...)
+}
+
+// hashFields call fn with hash field name of every exported field like redigo ScanStruct: name is redis tag or
+// field name, field tagged "-" is skipped and embedded struct is flattened
+func hashFields(v reflect.Value, fn func(field string, value reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			hashFields(v.Field(i), fn)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		field := f.Name
+		if tag := strings.Split(f.Tag.Get("redis"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			field = tag
+		}
+		fn(field, v.Field(i))
+	}
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// Hook observe every command run with Do and typed commands, commands of Pipeline and Tx are not observed
+type Hook interface {
+	// BeforeCommand is called before command is sent, returned context is passed to the command and AfterCommand
+	BeforeCommand(ctx context.Context, command string, args []interface{}) context.Context
+	// AfterCommand is called when command finished with its raw reply, error and duration, nil reply without error
+	// is a miss
+	AfterCommand(ctx context.Context, command string, args []interface{}, reply interface{}, err error, duration time.Duration)
+}
+
+// commandHooks return configured hooks preceded by metrics and tracing hook when enabled
+func commandHooks(config RedisConfig) []Hook {
+	hooks := config.Hooks
+	if config.EnableTracing {
+		hooks = append([]Hook{NewTracingHook(otel.Tracer(tracerName), config.HashTracedKeys)}, hooks...)
+	}
+	if config.EnableMetrics {
+		name := config.Name
+		if name == "" {
+			name = "redis"
+		}
+		hooks = append([]Hook{&metricsHook{name: name}}, hooks...)
+	}
+	return hooks
+}
+
+// runHooks call fn between BeforeCommand and AfterCommand of every hook, AfterCommand is called in reverse order
+func runHooks(ctx context.Context, hooks []Hook, command string, args []interface{}, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if len(hooks) == 0 {
+		return fn(ctx)
+	}
+
+	for _, hook := range hooks {
+		ctx = hook.BeforeCommand(ctx, command, args)
+	}
+
+	start := time.Now()
+	reply, err := fn(ctx)
+	duration := time.Since(start)
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].AfterCommand(ctx, command, args, reply, err, duration)
+	}
+	return reply, err
+}
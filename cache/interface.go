@@ -2,13 +2,17 @@ package cache
 
 import (
 	"context"
+	"time"
 )
 
 type ICache interface {
 	Ping() error
 
 	Do(ctx context.Context, command string, args ...interface{}) IReply
+	Pipeline(ctx context.Context, fn func(p Pipeliner) error) ([]IReply, error)
+	Tx(ctx context.Context, fn func(tx ITx) error) IReply
 	Exists(ctx context.Context, key string) (bool, error)
+	Scan(ctx context.Context, pattern string, count int) (IKeyIterator, error)
 	TTL(ctx context.Context, key string) IReply
 
 	//Incremental based value
@@ -17,16 +21,29 @@ type ICache interface {
 	Decr(ctx context.Context, key string) IReply
 	DecrBy(ctx context.Context, key string, decr int) IReply
 	Expire(ctx context.Context, key string, expire int) IReply
+	ExpireIn(ctx context.Context, key string, ttl time.Duration) IReply
 
 	//String based value
 	Get(ctx context.Context, key string) IReply
 	Set(ctx context.Context, key string, value interface{}) IReply
 	SetWithExpire(ctx context.Context, key string, expire int, value interface{}) IReply
 	SetNoExpire(ctx context.Context, key string, value interface{}) IReply
+	SetTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) IReply
+	SetOpts(ctx context.Context, key string, value interface{}, opts SetOptions) IReply
+	GetDel(ctx context.Context, key string) IReply
+	GetEx(ctx context.Context, key string, expire int) IReply
+	GetSet(ctx context.Context, key string, value interface{}) IReply
 	Del(ctx context.Context, key string) IReply
+	DelByPattern(ctx context.Context, pattern string) (int64, error)
+	MGet(ctx context.Context, keys ...string) IReply
+	MSet(ctx context.Context, pairs map[string]interface{}) IReply
+	MGetStruct(ctx context.Context, dest interface{}, keys ...string) error
+	MSetStruct(ctx context.Context, pairs map[string]interface{}) IReply
 	SetStruct(ctx context.Context, key string, value interface{}) IReply
 	SetStructWithExpire(ctx context.Context, key string, expire int, value interface{}) IReply
 	SetStructNoExpire(ctx context.Context, key string, value interface{}) IReply
+	SetStructTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) IReply
+	GetOrSet(ctx context.Context, key string, ttl time.Duration, dest interface{}, loader Loader) error
 
 	//Set based value
 	SAdd(ctx context.Context, key string, values ...string) IReply
@@ -34,6 +51,7 @@ type ICache interface {
 	SIsMember(ctx context.Context, key, value string) IReply
 	SMembers(ctx context.Context, key string) IReply
 	SCard(ctx context.Context, key string) IReply
+	SScan(ctx context.Context, key, pattern string, count int) (ISetIterator, error)
 
 	// Hash based value
 	HSet(ctx context.Context, name string, obj interface{}) IReply
@@ -41,14 +59,84 @@ type ICache interface {
 	HSetNoExpire(ctx context.Context, name string, obj interface{}) IReply
 	HGet(ctx context.Context, name, key string) IReply
 	HGetAll(ctx context.Context, name string) IReply
+	HMGet(ctx context.Context, name string, fields ...string) IReply
 	HDel(ctx context.Context, name string, key string) IReply
+	HIncrBy(ctx context.Context, name, field string, incr int) IReply
+	HExists(ctx context.Context, name, field string) (bool, error)
+	HKeys(ctx context.Context, name string) ([]string, error)
+	HVals(ctx context.Context, name string) IReply
+	HLen(ctx context.Context, name string) (int64, error)
+	HSetNX(ctx context.Context, name, field string, value interface{}) (bool, error)
+	HScan(ctx context.Context, key, pattern string, count int) (IHashIterator, error)
 
 	// Sorted Set based value
 	ZAdd(ctx context.Context, key string, value interface{}, score int) IReply
 	ZRem(ctx context.Context, key string, value interface{}) IReply
 	ZRange(ctx context.Context, values ...interface{}) IReply
 	ZInterStore(ctx context.Context, values ...interface{}) IReply
+	ZScan(ctx context.Context, key, pattern string, count int) (ISortedSetIterator, error)
+	ZScore(ctx context.Context, key string, member interface{}) IReply
+	ZIncrBy(ctx context.Context, key string, incr float64, member interface{}) IReply
+	ZCard(ctx context.Context, key string) IReply
+	ZRank(ctx context.Context, key string, member interface{}) IReply
+	ZRevRank(ctx context.Context, key string, member interface{}) IReply
+	ZRangeByScore(ctx context.Context, key, min, max string, offset, count int) IReply
+	ZRevRangeByScore(ctx context.Context, key, max, min string, offset, count int) IReply
+	ZRangeWithScores(ctx context.Context, key string, start, stop int) ([]Member, error)
+	ZRevRangeWithScores(ctx context.Context, key string, start, stop int) ([]Member, error)
+	ZRemRangeByScore(ctx context.Context, key, min, max string) IReply
 	// List based value
+	LPush(ctx context.Context, key string, values ...string) IReply
+	RPush(ctx context.Context, key string, values ...string) IReply
+	LPop(ctx context.Context, key string) IReply
+	RPop(ctx context.Context, key string) IReply
+	LRange(ctx context.Context, key string, start, stop int) IReply
+	LLen(ctx context.Context, key string) IReply
+	LTrim(ctx context.Context, key string, start, stop int) IReply
+	LRem(ctx context.Context, key string, count int, value string) IReply
+	BLPop(ctx context.Context, timeout time.Duration, keys ...string) IReply
+	BRPop(ctx context.Context, timeout time.Duration, keys ...string) IReply
+
+	// Bitmap based value
+	SetBit(ctx context.Context, key string, offset int64, value int) IReply
+	GetBit(ctx context.Context, key string, offset int64) IReply
+	BitCount(ctx context.Context, key string) IReply
+	BitOp(ctx context.Context, op, dest string, keys ...string) IReply
+
+	// HyperLogLog based value
+	PFAdd(ctx context.Context, key string, elements ...string) IReply
+	PFCount(ctx context.Context, keys ...string) IReply
+	PFMerge(ctx context.Context, dest string, keys ...string) IReply
+
+	// Geo based value
+	GeoAdd(ctx context.Context, key string, locations ...GeoLocation) IReply
+	GeoDist(ctx context.Context, key, member1, member2, unit string) IReply
+	GeoSearch(ctx context.Context, key string, query GeoSearchQuery) ([]GeoLocation, error)
+
+	// Bloom and cuckoo filter, require RedisBloom module
+	HasModule(ctx context.Context, name string) (bool, error)
+	BFReserve(ctx context.Context, key string, errorRate float64, capacity int64) IReply
+	BFAdd(ctx context.Context, key, item string) (bool, error)
+	BFMAdd(ctx context.Context, key string, items ...string) ([]bool, error)
+	BFExists(ctx context.Context, key, item string) (bool, error)
+	BFMExists(ctx context.Context, key string, items ...string) ([]bool, error)
+	CFReserve(ctx context.Context, key string, capacity int64) IReply
+	CFAdd(ctx context.Context, key, item string) (bool, error)
+	CFAddNX(ctx context.Context, key, item string) (bool, error)
+	CFExists(ctx context.Context, key, item string) (bool, error)
+	CFDel(ctx context.Context, key, item string) (bool, error)
+
+	// Stream based value
+	XAdd(ctx context.Context, stream string, maxLen int, values interface{}) IReply
+	XGroupCreate(ctx context.Context, stream, group, start string) error
+	XReadGroup(ctx context.Context, stream, group, consumer string, count int, block time.Duration) ([]StreamMessage, error)
+	XAck(ctx context.Context, stream, group string, ids ...string) IReply
+	XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int) (string, []StreamMessage, error)
+
+	// Pub/Sub and keyspace notification
+	Publish(ctx context.Context, channel string, message interface{}) IReply
+	Subscribe(ctx context.Context, channels ...string) (<-chan PubSubMessage, error)
+	SubscribeKeyEvents(ctx context.Context, pattern string) (<-chan KeyEvent, error)
 }
 
 type IReply interface {
@@ -59,6 +147,16 @@ type IReply interface {
 	Int() (int, error)
 	Bool() (bool, error)
 	Strings() ([]string, error)
+	Bytes() ([]byte, error)
+	ByteSlices() ([][]byte, error)
+	Ints() ([]int, error)
+	Int64s() ([]int64, error)
+	Float64s() ([]float64, error)
+	StringMap() (map[string]string, error)
+	IntMap() (map[string]int, error)
+	Int64Map() (map[string]int64, error)
+	Values() ([]interface{}, error)
 	Unmarshal(obj interface{}) error
+	UnmarshalSlice(dest interface{}) error
 	Struct(obj interface{}) error
 }
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+const ErrorFailedEnableKeyEvents = "Failed to enable redis keyspace notification on %s. Error: %s"
+
+// keyEventFlags notify-keyspace-events needed by SubscribeKeyEvents, K keyspace channel, x expired and e evicted
+const keyEventFlags = "Kxe"
+
+// KeyEvent key expired or evicted
+type KeyEvent struct {
+	// expired or evicted
+	Event string
+	Key   string
+}
+
+// keyEventNode run command and subscribe on one node
+type keyEventNode struct {
+	addr      string
+	do        func(ctx context.Context, args ...interface{}) (interface{}, error)
+	subscribe func(ctx context.Context, pattern string) (func(handle func(channel string, data []byte)), error)
+}
+
+// SubscribeKeyEvents stream expired and evicted events of keys matching pattern until ctx is done, channel is closed
+// after that. notify-keyspace-events is enabled with CONFIG SET when missing so it fail on server where CONFIG is
+// disabled unless notification is already configured. subscription hold one pooled connection per node, see Subscribe.
+// in cluster mode every master is subscribed
+func (r *Redis) SubscribeKeyEvents(ctx context.Context, pattern string) (<-chan KeyEvent, error) {
+	nodes, err := r.keyEventNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if err := enableKeyEvents(ctx, node.do); err != nil {
+			return nil, fmt.Errorf(ErrorFailedEnableKeyEvents, node.addr, err)
+		}
+	}
+
+	// key of event is returned without KeyPrefix
+	keyspace := fmt.Sprintf("__keyspace@%d__:%s", r.db, r.prefix)
+	handle := func(events chan<- KeyEvent) func(channel string, data []byte) {
+		return func(channel string, data []byte) {
+			event := string(data)
+			if event != "expired" && event != "evicted" {
+				return
+			}
+			select {
+			case events <- KeyEvent{Event: event, Key: strings.TrimPrefix(channel, keyspace)}:
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	// subscription already running is stopped when the next one fail
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan KeyEvent)
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		run, err := node.subscribe(ctx, keyspace+pattern)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(handle(events))
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(events)
+	}()
+	return events, nil
+}
+
+// keyEventNodes return every master in cluster mode or the only node
+func (r *Redis) keyEventNodes(ctx context.Context) ([]keyEventNode, error) {
+	if r.client != nil {
+		masters, err := r.goRedisMasters(ctx)
+		if err != nil {
+			return nil, err
+		}
+		nodes := make([]keyEventNode, len(masters))
+		for i, master := range masters {
+			master := master
+			nodes[i] = keyEventNode{
+				addr: master.Options().Addr,
+				do: func(ctx context.Context, args ...interface{}) (interface{}, error) {
+					return r.goRedisNodeDo(ctx, master, args)
+				},
+				subscribe: func(ctx context.Context, pattern string) (func(handle func(channel string, data []byte)), error) {
+					return goRedisSubscribe(ctx, master.PSubscribe(ctx, pattern), 1, r.timeout)
+				},
+			}
+		}
+		return nodes, nil
+	}
+
+	pools := map[string]*redis.Pool{r.connection: r.pool}
+	if r.cluster != nil {
+		pools = map[string]*redis.Pool{}
+		for _, addr := range r.cluster.masters() {
+			pools[addr] = r.cluster.pool(addr)
+		}
+	}
+	var nodes []keyEventNode
+	for addr, pool := range pools {
+		addr, pool := addr, pool
+		nodes = append(nodes, keyEventNode{
+			addr: addr,
+			do: func(ctx context.Context, args ...interface{}) (interface{}, error) {
+				timeout, err := commandTimeout(ctx, r.timeout)
+				if err != nil {
+					return nil, err
+				}
+				conn, err := pool.GetContext(ctx)
+				if err != nil {
+					return nil, err
+				}
+				defer conn.Close()
+				return conn.(redis.ConnWithTimeout).DoWithTimeout(timeout, keyString(args[0]), args[1:]...)
+			},
+			subscribe: func(ctx context.Context, pattern string) (func(handle func(channel string, data []byte)), error) {
+				s := &subscription{addr: addr, pool: pool, timeout: r.timeout, psubscribe: true,
+					channels: []interface{}{pattern}}
+				psc, err := s.dial(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return func(handle func(channel string, data []byte)) {
+					s.run(ctx, psc, handle)
+				}, nil
+			},
+		})
+	}
+	return nodes, nil
+}
+
+// enableKeyEvents add missing keyEventFlags to notify-keyspace-events
+func enableKeyEvents(ctx context.Context, do func(ctx context.Context, args ...interface{}) (interface{}, error)) error {
+	config, err := redis.StringMap(do(ctx, "CONFIG", "GET", "notify-keyspace-events"))
+	if err != nil {
+		return err
+	}
+	flags := config["notify-keyspace-events"]
+	missing := ""
+	for _, flag := range keyEventFlags {
+		// A is alias of every event type except key miss and new key
+		if !strings.ContainsRune(flags, flag) && (flag == 'K' || !strings.ContainsRune(flags, 'A')) {
+			missing += string(flag)
+		}
+	}
+	if missing == "" {
+		return nil
+	}
+	_, err = do(ctx, "CONFIG", "SET", "notify-keyspace-events", flags+missing)
+	return err
+}
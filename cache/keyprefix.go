@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"context"
+	"strings"
+)
+
+// prefixArgs return copy of args with KeyPrefix prepended to every key of command, see commandKeyIndexes
+func (r *Redis) prefixArgs(command string, args []interface{}) []interface{} {
+	if r.prefix == "" {
+		return args
+	}
+	indexes := commandKeyIndexes(strings.ToUpper(command), args)
+	if len(indexes) == 0 {
+		return args
+	}
+
+	prefixed := append([]interface{}(nil), args...)
+	for _, i := range indexes {
+		prefixed[i] = r.prefix + keyString(args[i])
+	}
+	return prefixed
+}
+
+// trimFetch remove KeyPrefix from keys returned by SCAN
+func (r *Redis) trimFetch(fetch scanFetch) scanFetch {
+	if r.prefix == "" {
+		return fetch
+	}
+	return func(ctx context.Context) ([]string, bool, error) {
+		keys, done, err := fetch(ctx)
+		for i, key := range keys {
+			keys[i] = strings.TrimPrefix(key, r.prefix)
+		}
+		return keys, done, err
+	}
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Lock is distributed lock owned by random token, only the owner can release or extend it.
+// with WithRedlock it is acquired on majority of independent redis nodes, Lock is not safe for concurrent use
+type Lock struct {
+	caches        []ICache
+	key           string
+	ttl           time.Duration
+	retryInterval time.Duration
+	token         string
+}
+
+type LockOption func(*Lock)
+
+var ErrLockNotHeld = errors.New("lock is not held, it was released or expired")
+
+// clockDriftFactor of ttl allowed between redlock nodes, 2ms is added like the reference algorithm
+const clockDriftFactor = 0.01
+
+var (
+	releaseScript = newLuaScript(1, `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+	extendScript = newLuaScript(1, `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// WithLockRetryInterval set wait between attempt of Acquire, by default 100 milliseconds
+func WithLockRetryInterval(interval time.Duration) LockOption {
+	return func(l *Lock) {
+		l.retryInterval = interval
+	}
+}
+
+// WithRedlock acquire lock on majority of cache and the rest of nodes, each node must be independent
+// redis master (not replica of each other)
+func WithRedlock(caches ...ICache) LockOption {
+	return func(l *Lock) {
+		l.caches = append(l.caches, caches...)
+	}
+}
+
+// NewLock create lock on key which expire after ttl unless extended
+func NewLock(cache ICache, key string, ttl time.Duration, opts ...LockOption) *Lock {
+	l := &Lock{caches: []ICache{cache}, key: key, ttl: ttl, retryInterval: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Acquire retry TryAcquire until lock is acquired or ctx is done
+func (l *Lock) Acquire(ctx context.Context) error {
+	for {
+		acquired, err := l.TryAcquire(ctx)
+		if err != nil || acquired {
+			return err
+		}
+
+		timer := time.NewTimer(l.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// TryAcquire attempt to acquire lock once, false is returned when it is held by another owner
+func (l *Lock) TryAcquire(ctx context.Context) (bool, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	acquired := 0
+	var lastErr error
+	for _, cache := range l.caches {
+		// NX miss is nil reply
+		_, err := cache.SetOpts(ctx, l.key, token, SetOptions{NX: true, PX: l.ttl.Milliseconds()}).String()
+		switch {
+		case err == nil:
+			acquired++
+		case err != ErrorNil:
+			lastErr = err
+		}
+	}
+
+	// lock is only valid when acquired on majority before it would expire
+	drift := time.Duration(float64(l.ttl)*clockDriftFactor) + 2*time.Millisecond
+	if acquired >= l.quorum() && time.Since(start)+drift < l.ttl {
+		l.token = token
+		return true, nil
+	}
+
+	// undo partial acquire so other owner does not wait for ttl
+	l.release(context.Background(), token)
+	if acquired == 0 && lastErr != nil {
+		return false, lastErr
+	}
+	return false, nil
+}
+
+// Release delete lock only when it is still owned
+func (l *Lock) Release(ctx context.Context) error {
+	if l.token == "" {
+		return ErrLockNotHeld
+	}
+	released, err := l.release(ctx, l.token)
+	l.token = ""
+	if err != nil {
+		return err
+	}
+	if released == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Extend reset lock ttl, ErrLockNotHeld is returned when it expired and may be taken by other owner
+func (l *Lock) Extend(ctx context.Context) error {
+	if l.token == "" {
+		return ErrLockNotHeld
+	}
+
+	extended := 0
+	var lastErr error
+	for _, cache := range l.caches {
+		n, err := extendScript.run(ctx, cache, l.key, l.token, l.ttl.Milliseconds()).Int()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		extended += n
+	}
+	if extended >= l.quorum() {
+		return nil
+	}
+	if extended == 0 && lastErr != nil {
+		return lastErr
+	}
+	return ErrLockNotHeld
+}
+
+// release delete lock on every node, number of node where it was still owned is returned
+func (l *Lock) release(ctx context.Context, token string) (int, error) {
+	released := 0
+	var lastErr error
+	for _, cache := range l.caches {
+		n, err := releaseScript.run(ctx, cache, l.key, token).Int()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		released += n
+	}
+	if released == 0 {
+		return 0, lastErr
+	}
+	return released, nil
+}
+
+func (l *Lock) quorum() int {
+	return len(l.caches)/2 + 1
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,645 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"golang.org/x/sync/singleflight"
+)
+
+type MemcachedConfig struct {
+	// list of memcached server in host:port format
+	Servers []string
+
+	// socket read/write timeout (in second), by default 1 second
+	Timeout int
+
+	MaxIdle int
+
+	// encoding of struct commands, by default JSONCodec
+	Codec Codec
+
+	// expire of Set and SetStruct rounded up to seconds, negative never expire, by default 15 minutes
+	DefaultTTL time.Duration
+}
+
+type Memcached struct {
+	servers    []string
+	client     *memcache.Client
+	codec      Codec
+	defaultTTL time.Duration
+	loads      singleflight.Group
+}
+
+const ErrorFailedConnectMemcached = "Failed to connect to memcached %v. Error: %s"
+
+// maxRelativeExpire memcached treats expiration longer than 30 days as unix timestamp
+const maxRelativeExpire = 60 * 60 * 24 * 30
+
+// ErrNotSupported command is not supported by the cache backend
+var ErrNotSupported = errors.New("command is not supported by this cache backend")
+
+// ConnectMemcached create memcached backed ICache, only string and struct based value are supported,
+// other commands return ErrNotSupported
+func ConnectMemcached(config MemcachedConfig) (ICache, error) {
+	client := memcache.New(config.Servers...)
+	client.Timeout = time.Second
+	if config.Timeout > 0 {
+		client.Timeout = time.Duration(config.Timeout) * time.Second
+	}
+	client.MaxIdleConns = config.MaxIdle
+
+	m := &Memcached{servers: config.Servers, client: client, codec: config.Codec, defaultTTL: defaultTTL(config.DefaultTTL)}
+	if err := m.Ping(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func notSupported() IReply {
+	return &Reply{result: nil, error: ErrNotSupported}
+}
+
+func memcachedExpire(expire int) int32 {
+	if expire > maxRelativeExpire {
+		return int32(time.Now().Unix()) + int32(expire)
+	}
+	return int32(expire)
+}
+
+func toBytes(value interface{}) []byte {
+	switch v := value.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	case nil:
+		return []byte{}
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
+
+func (m *Memcached) Ping() error {
+	if err := m.client.Ping(); err != nil {
+		return fmt.Errorf(ErrorFailedConnectMemcached, m.servers, err)
+	}
+	return nil
+}
+
+func (m *Memcached) Do(ctx context.Context, command string, args ...interface{}) IReply {
+	return notSupported()
+}
+
+func (m *Memcached) Pipeline(ctx context.Context, fn func(p Pipeliner) error) ([]IReply, error) {
+	return nil, ErrNotSupported
+}
+
+func (m *Memcached) Tx(ctx context.Context, fn func(tx ITx) error) IReply {
+	return notSupported()
+}
+
+func (m *Memcached) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := m.client.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *Memcached) Scan(ctx context.Context, pattern string, count int) (IKeyIterator, error) {
+	return nil, ErrNotSupported
+}
+
+func (m *Memcached) TTL(ctx context.Context, key string) IReply {
+	return notSupported()
+}
+
+func (m *Memcached) Incr(ctx context.Context, key string) IReply {
+	return m.IncrBy(ctx, key, 1)
+}
+
+// IncrBy increment value, missing key is initialized with 0 like redis
+func (m *Memcached) IncrBy(ctx context.Context, key string, incr int) IReply {
+	if incr < 0 {
+		return m.DecrBy(ctx, key, -incr)
+	}
+	return m.incr(key, func() (uint64, error) {
+		return m.client.Increment(key, uint64(incr))
+	})
+}
+
+func (m *Memcached) Decr(ctx context.Context, key string) IReply {
+	return m.DecrBy(ctx, key, 1)
+}
+
+// DecrBy decrement value, memcached value never goes below 0
+func (m *Memcached) DecrBy(ctx context.Context, key string, decr int) IReply {
+	if decr < 0 {
+		return m.IncrBy(ctx, key, -decr)
+	}
+	return m.incr(key, func() (uint64, error) {
+		return m.client.Decrement(key, uint64(decr))
+	})
+}
+
+func (m *Memcached) incr(key string, fn func() (uint64, error)) IReply {
+	value, err := fn()
+	if err == memcache.ErrCacheMiss {
+		err = m.client.Add(&memcache.Item{Key: key, Value: []byte("0")})
+		if err != nil && err != memcache.ErrNotStored {
+			return &Reply{result: nil, error: err}
+		}
+		value, err = fn()
+	}
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return &Reply{result: int64(value), error: nil}
+}
+
+func (m *Memcached) Expire(ctx context.Context, key string, expire int) IReply {
+	err := m.client.Touch(key, memcachedExpire(expire))
+	if err == memcache.ErrCacheMiss {
+		return &Reply{result: int64(0), error: nil}
+	}
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return &Reply{result: int64(1), error: nil}
+}
+
+// ExpireIn set expire rounded up to seconds, zero ttl remove existing expire
+func (m *Memcached) ExpireIn(ctx context.Context, key string, ttl time.Duration) IReply {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return m.Expire(ctx, key, int((ttl+time.Second-1)/time.Second))
+}
+
+func (m *Memcached) Get(ctx context.Context, key string) IReply {
+	item, err := m.client.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return &Reply{result: nil, error: ErrorNil}
+	}
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return &Reply{result: item.Value, error: nil, codec: codecFrom(ctx, m.codec)}
+}
+
+func (m *Memcached) set(key string, expire int, value interface{}) IReply {
+	err := m.client.Set(&memcache.Item{Key: key, Value: toBytes(value), Expiration: memcachedExpire(expire)})
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return &Reply{result: "OK", error: nil}
+}
+
+// SetOpts support NX with add, XX with replace and expire rounded up to seconds, KeepTTL and Get return ErrNotSupported
+func (m *Memcached) SetOpts(ctx context.Context, key string, value interface{}, opts SetOptions) IReply {
+	if err := opts.validate(); err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	if opts.KeepTTL || opts.Get {
+		return notSupported()
+	}
+
+	expire := opts.EX
+	if opts.PX > 0 {
+		expire = int((opts.PX + 999) / 1000)
+	}
+	item := &memcache.Item{Key: key, Value: toBytes(value), Expiration: memcachedExpire(expire)}
+
+	var err error
+	switch {
+	case opts.NX:
+		err = m.client.Add(item)
+	case opts.XX:
+		err = m.client.Replace(item)
+	default:
+		err = m.client.Set(item)
+	}
+	if err == memcache.ErrNotStored {
+		return &Reply{result: nil, error: ErrorNil}
+	}
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return &Reply{result: "OK", error: nil}
+}
+
+func (m *Memcached) Set(ctx context.Context, key string, value interface{}) IReply {
+	return m.SetTTL(ctx, key, value, m.defaultTTL)
+}
+
+func (m *Memcached) SetWithExpire(ctx context.Context, key string, expire int, value interface{}) IReply {
+	return m.set(key, expire, value)
+}
+
+func (m *Memcached) SetNoExpire(ctx context.Context, key string, value interface{}) IReply {
+	return m.set(key, 0, value)
+}
+
+// SetTTL set key with expire rounded up to seconds, zero ttl never expire
+func (m *Memcached) SetTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) IReply {
+	return m.SetOpts(ctx, key, value, SetOptions{PX: ttlMilliseconds(ttl)})
+}
+
+func (m *Memcached) Del(ctx context.Context, key string) IReply {
+	err := m.client.Delete(key)
+	if err == memcache.ErrCacheMiss {
+		return &Reply{result: int64(0), error: nil}
+	}
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return &Reply{result: int64(1), error: nil}
+}
+
+func (m *Memcached) DelByPattern(ctx context.Context, pattern string) (int64, error) {
+	return 0, ErrNotSupported
+}
+
+func (m *Memcached) SetStruct(ctx context.Context, key string, value interface{}) IReply {
+	encoded, err := codecFrom(ctx, m.codec).Marshal(value)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return m.Set(ctx, key, encoded)
+}
+
+func (m *Memcached) SetStructWithExpire(ctx context.Context, key string, expire int, value interface{}) IReply {
+	encoded, err := codecFrom(ctx, m.codec).Marshal(value)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return m.SetWithExpire(ctx, key, expire, encoded)
+}
+
+func (m *Memcached) SetStructNoExpire(ctx context.Context, key string, value interface{}) IReply {
+	encoded, err := codecFrom(ctx, m.codec).Marshal(value)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return m.SetNoExpire(ctx, key, encoded)
+}
+
+func (m *Memcached) SetStructTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) IReply {
+	encoded, err := codecFrom(ctx, m.codec).Marshal(value)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return m.SetTTL(ctx, key, encoded, ttl)
+}
+
+// GetDel get value then expire it with cas so only one caller get the value, eg: one-time token.
+// cas conflict is retried until ctx is done
+func (m *Memcached) GetDel(ctx context.Context, key string) IReply {
+	for {
+		if err := ctx.Err(); err != nil {
+			return &Reply{result: nil, error: err}
+		}
+		item, err := m.client.Get(key)
+		if err == memcache.ErrCacheMiss {
+			return &Reply{result: nil, error: ErrorNil}
+		}
+		if err != nil {
+			return &Reply{result: nil, error: err}
+		}
+
+		value := item.Value
+		// negative expiration expire item immediately
+		item.Expiration = -1
+		err = m.client.CompareAndSwap(item)
+		if err == memcache.ErrCASConflict {
+			continue
+		}
+		if err == memcache.ErrNotStored {
+			return &Reply{result: nil, error: ErrorNil}
+		}
+		if err != nil {
+			return &Reply{result: nil, error: err}
+		}
+		return &Reply{result: value, error: nil, codec: codecFrom(ctx, m.codec)}
+	}
+}
+
+// GetEx get value and set its expire in seconds with gat, expire 0 remove existing expire
+func (m *Memcached) GetEx(ctx context.Context, key string, expire int) IReply {
+	item, err := m.client.GetAndTouch(key, memcachedExpire(expire))
+	if err == memcache.ErrCacheMiss {
+		return &Reply{result: nil, error: ErrorNil}
+	}
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return &Reply{result: item.Value, error: nil, codec: codecFrom(ctx, m.codec)}
+}
+
+// GetSet swap value with cas so concurrent writer is not lost, stored without expire like redis.
+// cas conflict is retried until ctx is done
+func (m *Memcached) GetSet(ctx context.Context, key string, value interface{}) IReply {
+	for {
+		if err := ctx.Err(); err != nil {
+			return &Reply{result: nil, error: err}
+		}
+		item, err := m.client.Get(key)
+		if err == memcache.ErrCacheMiss {
+			err = m.client.Add(&memcache.Item{Key: key, Value: toBytes(value)})
+			if err == memcache.ErrNotStored {
+				continue
+			}
+			if err != nil {
+				return &Reply{result: nil, error: err}
+			}
+			return &Reply{result: nil, error: nil}
+		}
+		if err != nil {
+			return &Reply{result: nil, error: err}
+		}
+
+		old := item.Value
+		item.Value, item.Expiration = toBytes(value), 0
+		err = m.client.CompareAndSwap(item)
+		if err == memcache.ErrCASConflict || err == memcache.ErrNotStored {
+			continue
+		}
+		if err != nil {
+			return &Reply{result: nil, error: err}
+		}
+		return &Reply{result: old, error: nil}
+	}
+}
+
+// MGet get keys with one get_multi, reply has nil for missing key like redis
+func (m *Memcached) MGet(ctx context.Context, keys ...string) IReply {
+	items, err := m.client.GetMulti(keys)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if item, ok := items[key]; ok {
+			values[i] = item.Value
+		}
+	}
+	return &Reply{result: values, error: nil, codec: codecFrom(ctx, m.codec)}
+}
+
+// MSet set keys one by one without expire, memcached has no multi set so it is not atomic
+func (m *Memcached) MSet(ctx context.Context, pairs map[string]interface{}) IReply {
+	for key, value := range pairs {
+		if reply := m.set(key, 0, value); reply.Error() != nil {
+			return reply
+		}
+	}
+	return &Reply{result: "OK", error: nil}
+}
+
+func (m *Memcached) MGetStruct(ctx context.Context, dest interface{}, keys ...string) error {
+	return unmarshalValues(m.MGet(ctx, keys...), dest, len(keys))
+}
+
+func (m *Memcached) MSetStruct(ctx context.Context, pairs map[string]interface{}) IReply {
+	encoded, err := marshalPairs(codecFrom(ctx, m.codec), pairs)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	return m.MSet(ctx, encoded)
+}
+
+func (m *Memcached) SAdd(ctx context.Context, key string, values ...string) IReply {
+	return notSupported()
+}
+func (m *Memcached) SRem(ctx context.Context, key string, values ...string) IReply {
+	return notSupported()
+}
+func (m *Memcached) SIsMember(ctx context.Context, key, value string) IReply {
+	return notSupported()
+}
+func (m *Memcached) SMembers(ctx context.Context, key string) IReply {
+	return notSupported()
+}
+func (m *Memcached) SCard(ctx context.Context, key string) IReply {
+	return notSupported()
+}
+func (m *Memcached) SScan(ctx context.Context, key, pattern string, count int) (ISetIterator, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) HSet(ctx context.Context, name string, obj interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) HSetWithExpire(ctx context.Context, name string, expire int, obj interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) HSetNoExpire(ctx context.Context, name string, obj interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) HGet(ctx context.Context, name, key string) IReply {
+	return notSupported()
+}
+func (m *Memcached) HGetAll(ctx context.Context, name string) IReply {
+	return notSupported()
+}
+func (m *Memcached) HMGet(ctx context.Context, name string, fields ...string) IReply {
+	return notSupported()
+}
+func (m *Memcached) HDel(ctx context.Context, name, key string) IReply {
+	return notSupported()
+}
+func (m *Memcached) HIncrBy(ctx context.Context, name, field string, incr int) IReply {
+	return notSupported()
+}
+func (m *Memcached) HExists(ctx context.Context, name, field string) (bool, error) {
+	return false, ErrNotSupported
+}
+func (m *Memcached) HKeys(ctx context.Context, name string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) HVals(ctx context.Context, name string) IReply {
+	return notSupported()
+}
+func (m *Memcached) HLen(ctx context.Context, name string) (int64, error) {
+	return 0, ErrNotSupported
+}
+func (m *Memcached) HSetNX(ctx context.Context, name, field string, value interface{}) (bool, error) {
+	return false, ErrNotSupported
+}
+func (m *Memcached) HScan(ctx context.Context, key, pattern string, count int) (IHashIterator, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) ZAdd(ctx context.Context, key string, value interface{}, score int) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZRem(ctx context.Context, key string, value interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZRange(ctx context.Context, values ...interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZInterStore(ctx context.Context, values ...interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZScan(ctx context.Context, key, pattern string, count int) (ISortedSetIterator, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) ZScore(ctx context.Context, key string, member interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZIncrBy(ctx context.Context, key string, incr float64, member interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZCard(ctx context.Context, key string) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZRank(ctx context.Context, key string, member interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZRevRank(ctx context.Context, key string, member interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZRangeByScore(ctx context.Context, key, min, max string, offset, count int) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZRevRangeByScore(ctx context.Context, key, max, min string, offset, count int) IReply {
+	return notSupported()
+}
+func (m *Memcached) ZRangeWithScores(ctx context.Context, key string, start, stop int) ([]Member, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) ZRevRangeWithScores(ctx context.Context, key string, start, stop int) ([]Member, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) ZRemRangeByScore(ctx context.Context, key, min, max string) IReply {
+	return notSupported()
+}
+
+func (m *Memcached) LPush(ctx context.Context, key string, values ...string) IReply {
+	return notSupported()
+}
+func (m *Memcached) RPush(ctx context.Context, key string, values ...string) IReply {
+	return notSupported()
+}
+func (m *Memcached) LPop(ctx context.Context, key string) IReply {
+	return notSupported()
+}
+func (m *Memcached) RPop(ctx context.Context, key string) IReply {
+	return notSupported()
+}
+func (m *Memcached) LRange(ctx context.Context, key string, start, stop int) IReply {
+	return notSupported()
+}
+func (m *Memcached) LLen(ctx context.Context, key string) IReply {
+	return notSupported()
+}
+func (m *Memcached) LTrim(ctx context.Context, key string, start, stop int) IReply {
+	return notSupported()
+}
+func (m *Memcached) LRem(ctx context.Context, key string, count int, value string) IReply {
+	return notSupported()
+}
+func (m *Memcached) BLPop(ctx context.Context, timeout time.Duration, keys ...string) IReply {
+	return notSupported()
+}
+func (m *Memcached) BRPop(ctx context.Context, timeout time.Duration, keys ...string) IReply {
+	return notSupported()
+}
+
+func (m *Memcached) SetBit(ctx context.Context, key string, offset int64, value int) IReply {
+	return notSupported()
+}
+func (m *Memcached) GetBit(ctx context.Context, key string, offset int64) IReply {
+	return notSupported()
+}
+func (m *Memcached) BitCount(ctx context.Context, key string) IReply {
+	return notSupported()
+}
+func (m *Memcached) BitOp(ctx context.Context, op, dest string, keys ...string) IReply {
+	return notSupported()
+}
+
+func (m *Memcached) PFAdd(ctx context.Context, key string, elements ...string) IReply {
+	return notSupported()
+}
+func (m *Memcached) PFCount(ctx context.Context, keys ...string) IReply {
+	return notSupported()
+}
+func (m *Memcached) PFMerge(ctx context.Context, dest string, keys ...string) IReply {
+	return notSupported()
+}
+
+func (m *Memcached) GeoAdd(ctx context.Context, key string, locations ...GeoLocation) IReply {
+	return notSupported()
+}
+func (m *Memcached) GeoDist(ctx context.Context, key, member1, member2, unit string) IReply {
+	return notSupported()
+}
+func (m *Memcached) GeoSearch(ctx context.Context, key string, query GeoSearchQuery) ([]GeoLocation, error) {
+	return nil, ErrNotSupported
+}
+
+func (m *Memcached) HasModule(ctx context.Context, name string) (bool, error) {
+	return false, nil
+}
+func (m *Memcached) BFReserve(ctx context.Context, key string, errorRate float64, capacity int64) IReply {
+	return notSupported()
+}
+func (m *Memcached) BFAdd(ctx context.Context, key, item string) (bool, error) {
+	return false, ErrNotSupported
+}
+func (m *Memcached) BFMAdd(ctx context.Context, key string, items ...string) ([]bool, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) BFExists(ctx context.Context, key, item string) (bool, error) {
+	return false, ErrNotSupported
+}
+func (m *Memcached) BFMExists(ctx context.Context, key string, items ...string) ([]bool, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) CFReserve(ctx context.Context, key string, capacity int64) IReply {
+	return notSupported()
+}
+func (m *Memcached) CFAdd(ctx context.Context, key, item string) (bool, error) {
+	return false, ErrNotSupported
+}
+func (m *Memcached) CFAddNX(ctx context.Context, key, item string) (bool, error) {
+	return false, ErrNotSupported
+}
+func (m *Memcached) CFExists(ctx context.Context, key, item string) (bool, error) {
+	return false, ErrNotSupported
+}
+func (m *Memcached) CFDel(ctx context.Context, key, item string) (bool, error) {
+	return false, ErrNotSupported
+}
+
+func (m *Memcached) XAdd(ctx context.Context, stream string, maxLen int, values interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	return ErrNotSupported
+}
+func (m *Memcached) XReadGroup(ctx context.Context, stream, group, consumer string, count int, block time.Duration) ([]StreamMessage, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) XAck(ctx context.Context, stream, group string, ids ...string) IReply {
+	return notSupported()
+}
+func (m *Memcached) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int) (string, []StreamMessage, error) {
+	return "", nil, ErrNotSupported
+}
+func (m *Memcached) Publish(ctx context.Context, channel string, message interface{}) IReply {
+	return notSupported()
+}
+func (m *Memcached) Subscribe(ctx context.Context, channels ...string) (<-chan PubSubMessage, error) {
+	return nil, ErrNotSupported
+}
+func (m *Memcached) SubscribeKeyEvents(ctx context.Context, pattern string) (<-chan KeyEvent, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// commandCollector export command latency, errors and hit ratio of every redis connected with EnableMetrics
+type commandCollector struct {
+	latency *prometheus.HistogramVec
+	errors  *prometheus.CounterVec
+	hits    *prometheus.CounterVec
+	misses  *prometheus.CounterVec
+}
+
+type metricsHook struct {
+	name string
+}
+
+// hitCommands read commands counted as hit or miss, every key of MGET and field of HMGET is counted
+var hitCommands = map[string]bool{
+	"GET":    true,
+	"GETEX":  true,
+	"GETDEL": true,
+	"HGET":   true,
+	"MGET":   true,
+	"HMGET":  true,
+}
+
+var defaultCollector = newCommandCollector()
+
+// Collector return collector of cache metrics, register it once, eg: prometheus.MustRegister(cache.Collector())
+func Collector() prometheus.Collector {
+	return defaultCollector
+}
+
+func newCommandCollector() *commandCollector {
+	labels := []string{"cache", "command"}
+	return &commandCollector{
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_command_duration_seconds",
+			Help:    "Latency of cache commands",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_command_errors_total",
+			Help: "Number of failed cache commands",
+		}, labels),
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Number of keys found by read commands",
+		}, labels),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Number of keys not found by read commands",
+		}, labels),
+	}
+}
+
+func (c *commandCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.latency.Describe(ch)
+	c.errors.Describe(ch)
+	c.hits.Describe(ch)
+	c.misses.Describe(ch)
+}
+
+func (c *commandCollector) Collect(ch chan<- prometheus.Metric) {
+	c.latency.Collect(ch)
+	c.errors.Collect(ch)
+	c.hits.Collect(ch)
+	c.misses.Collect(ch)
+}
+
+func (h *metricsHook) BeforeCommand(ctx context.Context, command string, args []interface{}) context.Context {
+	return ctx
+}
+
+func (h *metricsHook) AfterCommand(ctx context.Context, command string, args []interface{}, reply interface{}, err error, duration time.Duration) {
+	command = strings.ToUpper(command)
+	defaultCollector.latency.WithLabelValues(h.name, command).Observe(duration.Seconds())
+	if err != nil {
+		defaultCollector.errors.WithLabelValues(h.name, command).Inc()
+		return
+	}
+	if !hitCommands[command] {
+		return
+	}
+
+	var hits, misses float64
+	values, ok := reply.([]interface{})
+	if !ok {
+		values = []interface{}{reply}
+	}
+	for _, value := range values {
+		if value == nil {
+			misses++
+		} else {
+			hits++
+		}
+	}
+	defaultCollector.hits.WithLabelValues(h.name, command).Add(hits)
+	defaultCollector.misses.WithLabelValues(h.name, command).Add(misses)
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// Pipeliner queue commands of Pipeline
+type Pipeliner interface {
+	// Send queue command, its reply is returned by Pipeline at the same index
+	Send(command string, args ...interface{})
+}
+
+type pipeline struct {
+	commands []pipelineCommand
+}
+
+type pipelineCommand struct {
+	name string
+	args []interface{}
+}
+
+func (p *pipeline) Send(command string, args ...interface{}) {
+	p.commands = append(p.commands, pipelineCommand{name: command, args: args})
+}
+
+// Pipeline send commands queued by fn in one batch on single connection and read their replies after,
+// command error is returned in its reply, error is only returned when fn or connection fail
+func (r *Redis) Pipeline(ctx context.Context, fn func(p Pipeliner) error) ([]IReply, error) {
+	p := &pipeline{}
+	if err := fn(p); err != nil {
+		return nil, err
+	}
+	if len(p.commands) == 0 {
+		return nil, nil
+	}
+	for i, command := range p.commands {
+		p.commands[i].args = r.prefixArgs(command.name, command.args)
+	}
+
+	timeout, err := commandTimeout(ctx, r.timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := withContext(ctx, func() (interface{}, error) {
+		if r.cluster != nil {
+			return r.cluster.pipeline(ctx, timeout, p.commands)
+		}
+		if r.client != nil {
+			return r.goRedisPipeline(ctx, timeout, p.commands)
+		}
+
+		conn, err := r.getConnection(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return runPipeline(conn, timeout, p.commands)
+	})
+	if err != nil {
+		return nil, err
+	}
+	codec := codecFrom(ctx, r.codec)
+	for _, reply := range replies.([]IReply) {
+		reply.(*Reply).codec = codec
+	}
+	return replies.([]IReply), nil
+}
+
+// runPipeline flush all commands then receive reply of each, timeout is applied to every receive
+func runPipeline(conn redis.ConnWithTimeout, timeout time.Duration, commands []pipelineCommand) ([]IReply, error) {
+	for _, command := range commands {
+		if err := conn.Send(command.name, command.args...); err != nil {
+			return nil, err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]IReply, len(commands))
+	for i := range commands {
+		result, err := conn.ReceiveWithTimeout(timeout)
+		if _, ok := err.(redis.Error); err != nil && !ok {
+			return nil, err
+		}
+		replies[i] = &Reply{result: result, error: err}
+	}
+	return replies, nil
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+// pubsubPing subscribed connection is pinged this often so dead connection is detected and redialed
+const pubsubPing = 30 * time.Second
+
+// PubSubMessage received by Subscribe
+type PubSubMessage struct {
+	Channel string
+	Data    []byte
+}
+
+// subscription keep connection subscribed to channels (or patterns) of one node
+type subscription struct {
+	addr       string
+	pool       *redis.Pool
+	timeout    time.Duration
+	psubscribe bool
+	channels   []interface{}
+}
+
+// Publish send message to every subscriber of channel, reply is number of subscriber which received it
+func (r *Redis) Publish(ctx context.Context, channel string, message interface{}) IReply {
+	return r.Do(ctx, "PUBLISH", channel, message)
+}
+
+// Subscribe stream message of channels until ctx is done, channel is closed after that. subscription is active
+// when it return, it hold one pooled connection and is redialed after connection error, message published while
+// reconnecting is lost
+func (r *Redis) Subscribe(ctx context.Context, channels ...string) (<-chan PubSubMessage, error) {
+	var run func(handle func(channel string, data []byte))
+	if r.client != nil {
+		var err error
+		if run, err = goRedisSubscribe(ctx, r.client.Subscribe(ctx, channels...), len(channels), r.timeout); err != nil {
+			return nil, err
+		}
+	} else {
+		s := &subscription{addr: r.connection, pool: r.pool, timeout: r.timeout, channels: redis.Args{}.AddFlat(channels)}
+		if r.cluster != nil {
+			// message is broadcast to every node so any node can be subscribed
+			s.addr = r.cluster.nodeAddr(-1)
+			s.pool = r.cluster.pool(s.addr)
+		}
+		psc, err := s.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		run = func(handle func(channel string, data []byte)) {
+			s.run(ctx, psc, handle)
+		}
+	}
+
+	messages := make(chan PubSubMessage)
+	go func() {
+		defer close(messages)
+		run(func(channel string, data []byte) {
+			select {
+			case messages <- PubSubMessage{Channel: channel, Data: data}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return messages, nil
+}
+
+// dial subscribe pooled connection and wait until every channel is confirmed
+func (s *subscription) dial(ctx context.Context) (redis.PubSubConn, error) {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return redis.PubSubConn{}, err
+	}
+	psc := redis.PubSubConn{Conn: conn}
+	if s.psubscribe {
+		err = psc.PSubscribe(s.channels...)
+	} else {
+		err = psc.Subscribe(s.channels...)
+	}
+	for confirmed := 0; err == nil && confirmed < len(s.channels); {
+		switch v := psc.ReceiveWithTimeout(s.timeout).(type) {
+		case redis.Subscription:
+			confirmed++
+		case error:
+			err = v
+		}
+	}
+	if err != nil {
+		psc.Close()
+		return redis.PubSubConn{}, err
+	}
+	return psc, nil
+}
+
+// run receive message until ctx is done, connection is redialed after retryInterval when it fail
+func (s *subscription) run(ctx context.Context, psc redis.PubSubConn, handle func(channel string, data []byte)) {
+	logger := log.WithFields(log.Fields{"connection": s.addr, "channels": fmt.Sprint(s.channels...)})
+	for {
+		err := s.receive(ctx, psc, handle)
+		psc.Close()
+		for ctx.Err() == nil && err != nil {
+			logger.WithFields(log.Fields{"error": err.Error()}).Error("Failed to receive redis pubsub message")
+			timer := time.NewTimer(retryInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+				psc, err = s.dial(ctx)
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// receive pass message to handle until ctx is done or connection fail
+func (s *subscription) receive(ctx context.Context, psc redis.PubSubConn, handle func(channel string, data []byte)) error {
+	// only this goroutine write after subscribe, reply is read by the loop below
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(pubsubPing)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if s.psubscribe {
+					psc.PUnsubscribe()
+				} else {
+					psc.Unsubscribe()
+				}
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := psc.Ping(""); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		switch v := psc.ReceiveWithTimeout(2 * pubsubPing).(type) {
+		case redis.Message:
+			handle(v.Channel, v.Data)
+		case redis.PMessage:
+			handle(v.Channel, v.Data)
+		case redis.Subscription:
+			if v.Count == 0 {
+				return ctx.Err()
+			}
+		case error:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return v
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+type RetryConfig struct {
+	// maximum attempts including the first one, command timed out after reaching redis may be applied twice,
+	// eg: INCR
+	// by default command is not retried
+	MaxAttempts int
+
+	// wait before the first retry, doubled on every next retry
+	// by default 50 milliseconds
+	Backoff time.Duration
+
+	// decide whether command failed with err is retried
+	// by default IsTransient
+	Retryable func(err error) bool
+}
+
+// IsTransient check err is temporary failure during failover or restart, eg: i/o timeout, connection reset,
+// LOADING while replica load dataset and READONLY when master is demoted. ctx error is not transient
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		return strings.HasPrefix(string(redisErr), "LOADING") || strings.HasPrefix(string(redisErr), "READONLY")
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// retry call fn until it succeed, return error which is not retryable or attempts are exhausted,
+// waiting is cut short when ctx is done
+func retry(ctx context.Context, config RetryConfig, fn func() error) error {
+	backoff := config.Backoff
+	if backoff <= 0 {
+		backoff = 50 * time.Millisecond
+	}
+	retryable := config.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= config.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// IKeyIterator walk keys returned by SCAN, next batch is fetched when current one is consumed.
+// SCAN may return the same key more than once and key modified during iteration may be missed
+type IKeyIterator interface {
+	// Next advance to the next key, false is returned when iteration finish or fail
+	Next() bool
+	Key() string
+	// Err return error which stopped iteration
+	Err() error
+}
+
+// scanFetch return next batch and whether it is the last one
+type scanFetch func(ctx context.Context) ([]string, bool, error)
+
+type scanIterator struct {
+	ctx     context.Context
+	fetch   scanFetch
+	items   []string
+	current string
+	done    bool
+	err     error
+}
+
+// Scan iterate keys matching pattern with SCAN, count is hint of keys per batch (0 for server default),
+// first batch is fetched immediately so connection error is returned here. in cluster mode every master is scanned.
+// with KeyPrefix only prefixed keys are matched and they are returned without prefix
+func (r *Redis) Scan(ctx context.Context, pattern string, count int) (IKeyIterator, error) {
+	if r.prefix != "" {
+		if pattern == "" {
+			pattern = "*"
+		}
+		pattern = r.prefix + pattern
+	}
+	args := scanArgs(pattern, count)
+	fetch := cursorFetch(func(ctx context.Context, cursor string) (string, []string, error) {
+		return parseScan(r.do(ctx, r.timeout, "SCAN", append([]interface{}{cursor}, args...)))
+	})
+	if r.cluster != nil {
+		fetch = r.cluster.scanMasters(r.timeout, args)
+	}
+	if r.clustered() && r.client != nil {
+		var err error
+		if fetch, err = r.goRedisScanMasters(ctx, args); err != nil {
+			return nil, err
+		}
+	}
+
+	it, err := newScanIterator(ctx, r.trimFetch(fetch))
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+// cursorFetch keep cursor between call of scan, iteration finish when server return cursor 0
+func cursorFetch(scan func(ctx context.Context, cursor string) (string, []string, error)) scanFetch {
+	cursor := "0"
+	return func(ctx context.Context) ([]string, bool, error) {
+		next, items, err := scan(ctx, cursor)
+		if err != nil {
+			return nil, false, err
+		}
+		cursor = next
+		return items, next == "0", nil
+	}
+}
+
+// nodeScan run SCAN with cursor on one node
+type nodeScan func(ctx context.Context, cursor string) (string, []string, error)
+
+// scanNodes return fetch running SCAN on every node one after another, eg: every master of cluster
+func scanNodes(scans []nodeScan) scanFetch {
+	i := 0
+	cursor := "0"
+	return func(ctx context.Context) ([]string, bool, error) {
+		if len(scans) == 0 {
+			return nil, true, nil
+		}
+		var items []string
+		var err error
+		if cursor, items, err = scans[i](ctx, cursor); err != nil {
+			return nil, false, err
+		}
+		if cursor == "0" {
+			i++
+		}
+		return items, i == len(scans), nil
+	}
+}
+
+func newScanIterator(ctx context.Context, fetch scanFetch) (*scanIterator, error) {
+	it := &scanIterator{ctx: ctx, fetch: fetch}
+	if err := it.load(); err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+func (it *scanIterator) Next() bool {
+	if !it.fill() {
+		return false
+	}
+	it.current, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// nextBatch take every buffered item, see HScan
+func (it *scanIterator) nextBatch() ([]string, bool) {
+	if !it.fill() {
+		return nil, false
+	}
+	batch := it.items
+	it.items = nil
+	return batch, true
+}
+
+// fill fetch until there is buffered item, empty batch is valid SCAN reply
+func (it *scanIterator) fill() bool {
+	for len(it.items) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		if it.err = it.load(); it.err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (it *scanIterator) Key() string {
+	return it.current
+}
+
+func (it *scanIterator) Err() error {
+	return it.err
+}
+
+func (it *scanIterator) load() error {
+	items, done, err := it.fetch(it.ctx)
+	if err != nil {
+		return err
+	}
+	it.items, it.done = items, done
+	return nil
+}
+
+func scanArgs(pattern string, count int) []interface{} {
+	var args []interface{}
+	if pattern != "" {
+		args = append(args, "MATCH", pattern)
+	}
+	if count > 0 {
+		args = append(args, "COUNT", count)
+	}
+	return args
+}
+
+// parseScan parse [cursor, [item...]] reply of SCAN family
+func parseScan(reply *Reply) (string, []string, error) {
+	values, err := redis.Values(reply.result, reply.error)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(values) != 2 {
+		return "", nil, fmt.Errorf("unexpected scan reply %v", values)
+	}
+	cursor, err := redis.String(values[0], nil)
+	if err != nil {
+		return "", nil, err
+	}
+	items, err := redis.Strings(values[1], nil)
+	return cursor, items, err
+}
+
+// delByPatternBatch keys deleted by one command of DelByPattern
+const delByPatternBatch = 500
+
+// DelByPattern delete keys matching pattern in batches while scanning, UNLINK is used so memory is freed
+// in background and DEL on server older than redis 4. number of deleted keys is returned even when it fail midway
+func (r *Redis) DelByPattern(ctx context.Context, pattern string) (int64, error) {
+	it, err := r.Scan(ctx, pattern, delByPatternBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	command := "UNLINK"
+	batch := make([]interface{}, 0, delByPatternBatch)
+	flush := func() error {
+		n, err := r.delKeys(ctx, command, batch)
+		if err != nil && command == "UNLINK" && strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+			command = "DEL"
+			n, err = r.delKeys(ctx, command, batch)
+		}
+		deleted += n
+		batch = batch[:0]
+		return err
+	}
+
+	for it.Next() {
+		batch = append(batch, it.Key())
+		if len(batch) == delByPatternBatch {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return deleted, err
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// delKeys delete keys with one command, in cluster mode keys are in different slots so each is deleted
+// with its own command in pipeline
+func (r *Redis) delKeys(ctx context.Context, command string, keys []interface{}) (int64, error) {
+	if !r.clustered() {
+		return r.do(ctx, r.timeout, command, keys).Int64()
+	}
+
+	replies, err := r.Pipeline(ctx, func(p Pipeliner) error {
+		for _, key := range keys {
+			p.Send(command, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, reply := range replies {
+		n, err := reply.Int64()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	return deleted, nil
+}
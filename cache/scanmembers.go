@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// IHashIterator walk hash with HSCAN batch by batch, field may be returned more than once
+type IHashIterator interface {
+	// Next advance to the next batch, false is returned when iteration finish or fail
+	Next() bool
+	// Batch return field to value of current batch
+	Batch() map[string]string
+	Err() error
+}
+
+// ISetIterator walk set with SSCAN batch by batch, member may be returned more than once
+type ISetIterator interface {
+	Next() bool
+	Batch() []string
+	Err() error
+}
+
+// ISortedSetIterator walk sorted set with ZSCAN batch by batch, member may be returned more than once
+type ISortedSetIterator interface {
+	Next() bool
+	Batch() []Member
+	Err() error
+}
+
+type hashIterator struct {
+	it    *scanIterator
+	batch map[string]string
+	err   error
+}
+
+type setIterator struct {
+	it    *scanIterator
+	batch []string
+}
+
+type sortedSetIterator struct {
+	it    *scanIterator
+	batch []Member
+	err   error
+}
+
+// HScan iterate fields of hash matching pattern, count is hint of fields per batch (0 for server default)
+func (r *Redis) HScan(ctx context.Context, key, pattern string, count int) (IHashIterator, error) {
+	it, err := r.scanKey(ctx, "HSCAN", key, pattern, count)
+	if err != nil {
+		return nil, err
+	}
+	return &hashIterator{it: it}, nil
+}
+
+// SScan iterate members of set matching pattern, count is hint of members per batch (0 for server default)
+func (r *Redis) SScan(ctx context.Context, key, pattern string, count int) (ISetIterator, error) {
+	it, err := r.scanKey(ctx, "SSCAN", key, pattern, count)
+	if err != nil {
+		return nil, err
+	}
+	return &setIterator{it: it}, nil
+}
+
+// ZScan iterate members of sorted set matching pattern with their score, count is hint of members per batch
+// (0 for server default)
+func (r *Redis) ZScan(ctx context.Context, key, pattern string, count int) (ISortedSetIterator, error) {
+	it, err := r.scanKey(ctx, "ZSCAN", key, pattern, count)
+	if err != nil {
+		return nil, err
+	}
+	return &sortedSetIterator{it: it}, nil
+}
+
+func (r *Redis) scanKey(ctx context.Context, command, key, pattern string, count int) (*scanIterator, error) {
+	args := scanArgs(pattern, count)
+	return newScanIterator(ctx, cursorFetch(func(ctx context.Context, cursor string) (string, []string, error) {
+		return parseScan(r.do(ctx, r.timeout, command, append([]interface{}{key, cursor}, args...)))
+	}))
+}
+
+func (h *hashIterator) Next() bool {
+	items, ok := h.it.nextBatch()
+	if !ok {
+		return false
+	}
+	if len(items)%2 != 0 {
+		h.err = fmt.Errorf("unexpected HSCAN reply %v", items)
+		return false
+	}
+
+	h.batch = make(map[string]string, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		h.batch[items[i]] = items[i+1]
+	}
+	return true
+}
+
+func (h *hashIterator) Batch() map[string]string {
+	return h.batch
+}
+
+func (h *hashIterator) Err() error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.it.Err()
+}
+
+func (s *setIterator) Next() bool {
+	var ok bool
+	s.batch, ok = s.it.nextBatch()
+	return ok
+}
+
+func (s *setIterator) Batch() []string {
+	return s.batch
+}
+
+func (s *setIterator) Err() error {
+	return s.it.Err()
+}
+
+func (z *sortedSetIterator) Next() bool {
+	items, ok := z.it.nextBatch()
+	if !ok {
+		return false
+	}
+	z.batch, z.err = parseMembers(items, nil)
+	return z.err == nil
+}
+
+func (z *sortedSetIterator) Batch() []Member {
+	return z.batch
+}
+
+func (z *sortedSetIterator) Err() error {
+	if z.err != nil {
+		return z.err
+	}
+	return z.it.Err()
+}
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// luaScript run with EVALSHA and fallback to EVAL when script is not cached by server yet
+type luaScript struct {
+	keyCount int
+	src      string
+	hash     string
+}
+
+func newLuaScript(keyCount int, src string) *luaScript {
+	h := sha1.Sum([]byte(src))
+	return &luaScript{keyCount: keyCount, src: src, hash: hex.EncodeToString(h[:])}
+}
+
+// run script with keys followed by args
+func (s *luaScript) run(ctx context.Context, cache ICache, keysAndArgs ...interface{}) IReply {
+	reply := cache.Do(ctx, "EVALSHA", redis.Args{}.Add(s.hash, s.keyCount).Add(keysAndArgs...)...)
+	if err := reply.Error(); err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		return cache.Do(ctx, "EVAL", redis.Args{}.Add(s.src, s.keyCount).Add(keysAndArgs...)...)
+	}
+	return reply
+}
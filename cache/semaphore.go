@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Semaphore is distributed counting semaphore allowing up to limit holders, waiters acquire in arrival order.
+// holder which does not Release or Extend within ttl is considered dead and its permit is freed,
+// Semaphore hold at most one permit and is not safe for concurrent use
+type Semaphore struct {
+	cache         ICache
+	keys          []interface{}
+	limit         int
+	ttl           time.Duration
+	retryInterval time.Duration
+	token         string
+}
+
+type SemaphoreOption func(*Semaphore)
+
+var ErrSemaphoreNotHeld = errors.New("semaphore permit is not held, it was released or expired")
+
+// semaphore state is kept in holders (token by expire time), queue (token by arrival) and waiters (token by last
+// attempt) sorted set, time is taken from redis so instance clock skew does not matter
+var (
+	semaphoreAcquireScript = newLuaScript(4, `local holders, queue, waiters, seq = KEYS[1], KEYS[2], KEYS[3], KEYS[4]
+local token, limit, ttl = ARGV[1], tonumber(ARGV[2]), tonumber(ARGV[3])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", holders, "-inf", now)
+for _, waiter in ipairs(redis.call("ZRANGEBYSCORE", waiters, "-inf", now - ttl)) do
+	redis.call("ZREM", queue, waiter)
+	redis.call("ZREM", waiters, waiter)
+end
+
+if not redis.call("ZSCORE", queue, token) then
+	redis.call("ZADD", queue, redis.call("INCR", seq), token)
+end
+redis.call("ZADD", waiters, now, token)
+for _, key in ipairs(KEYS) do
+	redis.call("PEXPIRE", key, ttl * 2)
+end
+
+if redis.call("ZRANK", queue, token) < limit - redis.call("ZCARD", holders) then
+	redis.call("ZREM", queue, token)
+	redis.call("ZREM", waiters, token)
+	redis.call("ZADD", holders, now + ttl, token)
+	return 1
+end
+if ARGV[4] ~= "1" then
+	redis.call("ZREM", queue, token)
+	redis.call("ZREM", waiters, token)
+end
+return 0`)
+
+	// extend keep every key alive like acquire, so holders is not lost while permits are only extended
+	semaphoreExtendScript = newLuaScript(4, `local ttl = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+if redis.call("ZSCORE", KEYS[1], ARGV[1]) then
+	redis.call("ZADD", KEYS[1], now + ttl, ARGV[1])
+	for _, key in ipairs(KEYS) do
+		redis.call("PEXPIRE", key, ttl * 2)
+	end
+	return 1
+end
+return 0`)
+
+	semaphoreReleaseScript = newLuaScript(3, `redis.call("ZREM", KEYS[2], ARGV[1])
+redis.call("ZREM", KEYS[3], ARGV[1])
+return redis.call("ZREM", KEYS[1], ARGV[1])`)
+)
+
+// WithSemaphoreTTL set how long permit is held without Extend, by default 30 seconds
+func WithSemaphoreTTL(ttl time.Duration) SemaphoreOption {
+	return func(s *Semaphore) {
+		s.ttl = ttl
+	}
+}
+
+// WithSemaphoreRetryInterval set wait between attempt of Acquire, by default 100 milliseconds,
+// waiter which does not retry within ttl lose its place in queue
+func WithSemaphoreRetryInterval(interval time.Duration) SemaphoreOption {
+	return func(s *Semaphore) {
+		s.retryInterval = interval
+	}
+}
+
+// NewSemaphore create semaphore on key allowing limit concurrent holders, every key used by semaphore share
+// {key} hash tag so it works in cluster mode
+func NewSemaphore(cache ICache, key string, limit int, opts ...SemaphoreOption) *Semaphore {
+	tag := "{" + key + "}"
+	s := &Semaphore{
+		cache:         cache,
+		keys:          []interface{}{tag + ":holders", tag + ":queue", tag + ":waiters", tag + ":seq"},
+		limit:         limit,
+		ttl:           30 * time.Second,
+		retryInterval: 100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Acquire wait in queue until permit is acquired or ctx is done
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if s.token != "" {
+		return nil
+	}
+	token, err := newLockToken()
+	if err != nil {
+		return err
+	}
+
+	for {
+		acquired, err := s.acquire(ctx, token, true)
+		if err != nil || acquired {
+			if err != nil {
+				s.leave(token)
+			}
+			return err
+		}
+
+		timer := time.NewTimer(s.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.leave(token)
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// TryAcquire attempt to acquire permit once without joining the queue
+func (s *Semaphore) TryAcquire(ctx context.Context) (bool, error) {
+	if s.token != "" {
+		return true, nil
+	}
+	token, err := newLockToken()
+	if err != nil {
+		return false, err
+	}
+	return s.acquire(ctx, token, false)
+}
+
+func (s *Semaphore) acquire(ctx context.Context, token string, wait bool) (bool, error) {
+	waitArg := "0"
+	if wait {
+		waitArg = "1"
+	}
+	args := append(append([]interface{}(nil), s.keys...), token, s.limit, s.ttl.Milliseconds(), waitArg)
+	acquired, err := semaphoreAcquireScript.run(ctx, s.cache, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	if acquired == 1 {
+		s.token = token
+	}
+	return acquired == 1, nil
+}
+
+// Extend reset permit ttl, ErrSemaphoreNotHeld is returned when it expired and may be taken by other holder
+func (s *Semaphore) Extend(ctx context.Context) error {
+	if s.token == "" {
+		return ErrSemaphoreNotHeld
+	}
+	args := append(append([]interface{}(nil), s.keys...), s.token, s.ttl.Milliseconds())
+	extended, err := semaphoreExtendScript.run(ctx, s.cache, args...).Int()
+	if err != nil {
+		return err
+	}
+	if extended == 0 {
+		return ErrSemaphoreNotHeld
+	}
+	return nil
+}
+
+// Release free permit so the next waiter can acquire it
+func (s *Semaphore) Release(ctx context.Context) error {
+	if s.token == "" {
+		return ErrSemaphoreNotHeld
+	}
+	released, err := semaphoreReleaseScript.run(ctx, s.cache, s.keys[0], s.keys[1], s.keys[2], s.token).Int()
+	s.token = ""
+	if err != nil {
+		return err
+	}
+	if released == 0 {
+		return ErrSemaphoreNotHeld
+	}
+	return nil
+}
+
+// leave remove token from queue so it does not block waiter behind it until it is stale
+func (s *Semaphore) leave(token string) {
+	semaphoreReleaseScript.run(context.Background(), s.cache, s.keys[0], s.keys[1], s.keys[2], token)
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+type SentinelConfig struct {
+	// name of the master monitored by sentinel
+	Master string
+	// list of sentinel in host:port format
+	Addrs []string
+	// password of sentinel, by default sentinel does not require auth
+	SentinelPassword string
+
+	// the rest is applied to connection to master, see RedisConfig
+	Username       string
+	Password       string
+	DB             int
+	Timeout        int
+	MaxIdle        int
+	MaxActive      int
+	Codec          Codec
+	KeyPrefix      string
+	DefaultTTL     time.Duration
+	Name           string
+	Hooks          []Hook
+	EnableMetrics  bool
+	EnableTracing  bool
+	HashTracedKeys bool
+	Retry          RetryConfig
+}
+
+type sentinel struct {
+	mu       sync.Mutex
+	master   string
+	addrs    []string
+	password string
+	timeout  time.Duration
+}
+
+const ErrorFailedDiscoverMaster = "Failed to discover redis master %s from sentinel. Error: %s"
+
+var ErrNotMaster = errors.New("redis instance is not master")
+
+// roleCheckIdle connection idle longer than this is checked that it still talks to master before reuse
+const roleCheckIdle = time.Second
+
+// ConnectRedisSentinel create redis ICache which discover master address from sentinel on every new connection,
+// after failover connection to demoted master is dropped and new one is dialed to the promoted master
+func ConnectRedisSentinel(config SentinelConfig) (ICache, error) {
+	timeout := time.Duration(config.Timeout) * time.Second
+	s := &sentinel{
+		master:   config.Master,
+		addrs:    append([]string(nil), config.Addrs...),
+		password: config.SentinelPassword,
+		timeout:  timeout,
+	}
+
+	redisConfig := RedisConfig{
+		Username:       config.Username,
+		Password:       config.Password,
+		DB:             config.DB,
+		Timeout:        config.Timeout,
+		MaxIdle:        config.MaxIdle,
+		MaxActive:      config.MaxActive,
+		Codec:          config.Codec,
+		KeyPrefix:      config.KeyPrefix,
+		DefaultTTL:     config.DefaultTTL,
+		Name:           config.Name,
+		Hooks:          config.Hooks,
+		EnableMetrics:  config.EnableMetrics,
+		EnableTracing:  config.EnableTracing,
+		HashTracedKeys: config.HashTracedKeys,
+		Retry:          config.Retry,
+	}
+	pool := newRedisPool(redisConfig, func() (redis.Conn, error) {
+		addr, err := s.masterAddr()
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := redisConfig
+		cfg.Connection = addr
+		conn, err := dialRedis(cfg, timeout)
+		if err != nil {
+			return nil, err
+		}
+		// sentinel may still advertise old master right after failover
+		if err = checkMaster(conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	})
+	pool.TestOnBorrow = func(conn redis.Conn, returned time.Time) error {
+		if time.Since(returned) < roleCheckIdle {
+			return nil
+		}
+		return checkMaster(conn)
+	}
+
+	return connectPool(pool, "sentinel master "+config.Master, redisConfig)
+}
+
+// masterAddr ask sentinels for current master address, sentinel which answered is tried first next time
+func (s *sentinel) masterAddr() (string, error) {
+	s.mu.Lock()
+	addrs := append([]string(nil), s.addrs...)
+	s.mu.Unlock()
+
+	var err error
+	for i, addr := range addrs {
+		var master string
+		if master, err = s.queryMaster(addr); err != nil {
+			continue
+		}
+		if i > 0 {
+			s.promote(addr)
+		}
+		return master, nil
+	}
+	if err == nil {
+		err = errors.New("no sentinel configured")
+	}
+	return "", fmt.Errorf(ErrorFailedDiscoverMaster, s.master, err)
+}
+
+func (s *sentinel) queryMaster(addr string) (string, error) {
+	conn, err := redis.Dial("tcp", addr, redis.DialConnectTimeout(s.timeout), redis.DialReadTimeout(s.timeout),
+		redis.DialWriteTimeout(s.timeout), redis.DialPassword(s.password))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	reply, err := redis.Strings(conn.Do("SENTINEL", "get-master-addr-by-name", s.master))
+	if err != nil {
+		return "", err
+	}
+	if len(reply) != 2 {
+		return "", fmt.Errorf("unexpected sentinel reply %v", reply)
+	}
+	return net.JoinHostPort(reply[0], reply[1]), nil
+}
+
+func (s *sentinel) promote(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, a := range s.addrs {
+		if a == addr {
+			copy(s.addrs[1:i+1], s.addrs[:i])
+			s.addrs[0] = addr
+			return
+		}
+	}
+}
+
+func checkMaster(conn redis.Conn) error {
+	reply, err := redis.Values(conn.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	if len(reply) == 0 {
+		return ErrNotMaster
+	}
+	role, err := redis.String(reply[0], nil)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(role, "master") {
+		return ErrNotMaster
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// Member of sorted set with its score
+type Member struct {
+	Value string
+	Score float64
+}
+
+func (r *Redis) ZScore(ctx context.Context, key string, member interface{}) IReply {
+	return r.Do(ctx, "ZSCORE", key, member)
+}
+
+// ZIncrBy increment score of member, member is added when missing, reply is the new score
+func (r *Redis) ZIncrBy(ctx context.Context, key string, incr float64, member interface{}) IReply {
+	return r.Do(ctx, "ZINCRBY", key, incr, member)
+}
+
+func (r *Redis) ZCard(ctx context.Context, key string) IReply {
+	return r.Do(ctx, "ZCARD", key)
+}
+
+// ZRank return 0-based rank by ascending score, reply is nil when member does not exist
+func (r *Redis) ZRank(ctx context.Context, key string, member interface{}) IReply {
+	return r.Do(ctx, "ZRANK", key, member)
+}
+
+// ZRevRank return 0-based rank by descending score, eg: leaderboard position
+func (r *Redis) ZRevRank(ctx context.Context, key string, member interface{}) IReply {
+	return r.Do(ctx, "ZREVRANK", key, member)
+}
+
+// ZRangeByScore return members with score between min and max (eg: "-inf", "(10" for exclusive),
+// count > 0 limit result to count members after skipping offset
+func (r *Redis) ZRangeByScore(ctx context.Context, key, min, max string, offset, count int) IReply {
+	return r.Do(ctx, "ZRANGEBYSCORE", withLimit(redis.Args{}.Add(key, min, max), offset, count)...)
+}
+
+// ZRevRangeByScore return members with score between max and min ordered by descending score, see ZRangeByScore
+func (r *Redis) ZRevRangeByScore(ctx context.Context, key, max, min string, offset, count int) IReply {
+	return r.Do(ctx, "ZREVRANGEBYSCORE", withLimit(redis.Args{}.Add(key, max, min), offset, count)...)
+}
+
+// ZRangeWithScores return members between start and stop rank (inclusive, negative count from the end) with score
+func (r *Redis) ZRangeWithScores(ctx context.Context, key string, start, stop int) ([]Member, error) {
+	return parseMembers(r.Do(ctx, "ZRANGE", key, start, stop, "WITHSCORES").Strings())
+}
+
+// ZRevRangeWithScores return members between start and stop rank by descending score with score, eg: top 10
+func (r *Redis) ZRevRangeWithScores(ctx context.Context, key string, start, stop int) ([]Member, error) {
+	return parseMembers(r.Do(ctx, "ZREVRANGE", key, start, stop, "WITHSCORES").Strings())
+}
+
+// ZRemRangeByScore remove members with score between min and max, reply is number of removed members
+func (r *Redis) ZRemRangeByScore(ctx context.Context, key, min, max string) IReply {
+	return r.Do(ctx, "ZREMRANGEBYSCORE", key, min, max)
+}
+
+func withLimit(args redis.Args, offset, count int) redis.Args {
+	if count > 0 {
+		args = args.Add("LIMIT", offset, count)
+	}
+	return args
+}
+
+// parseMembers parse member, score pairs of WITHSCORES and ZSCAN reply
+func parseMembers(pairs []string, err error) ([]Member, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("unexpected member and score reply %v", pairs)
+	}
+
+	members := make([]Member, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		score, err := strconv.ParseFloat(pairs[i+1], 64)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, Member{Value: pairs[i], Score: score})
+	}
+	return members, nil
+}
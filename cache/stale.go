@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"path"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// staleHeader length of stored-at time prepended to value stored by Stale
+const staleHeader = 8
+
+var errStaleEntry = errors.New("cached value is not a stale-while-revalidate entry")
+
+// staleRule soft ttl of keys matching pattern (path.Match syntax, eg: user:*)
+type staleRule struct {
+	pattern string
+	// entry older than softTTL is returned as is and refreshed in background, zero never refresh
+	softTTL time.Duration
+}
+
+// Stale serve value of GetOrSet stale-while-revalidate: entry older than soft ttl of its key is returned immediately
+// while one background load replace it, so caller only wait for loader when key is missing. ttl of GetOrSet is the
+// hard expire and should be longer than soft ttl. entry is stored with its stored-at time so key written by Stale
+// must only be read by Stale.GetOrSet, other commands go to the wrapped ICache
+type Stale struct {
+	ICache
+	rules   []staleRule
+	softTTL time.Duration
+	codec   Codec
+
+	loads singleflight.Group
+}
+
+type StaleOption func(s *Stale)
+
+// WithStaleRule soft ttl of keys matching pattern, rules are matched in order they are added
+func WithStaleRule(pattern string, softTTL time.Duration) StaleOption {
+	return func(s *Stale) {
+		s.rules = append(s.rules, staleRule{pattern: pattern, softTTL: softTTL})
+	}
+}
+
+// WithStaleSoftTTL soft ttl of keys matching no rule, by default 1 minute
+func WithStaleSoftTTL(softTTL time.Duration) StaleOption {
+	return func(s *Stale) {
+		s.softTTL = softTTL
+	}
+}
+
+// NewStale wrap cache with stale-while-revalidate GetOrSet
+func NewStale(cache ICache, opts ...StaleOption) *Stale {
+	s := &Stale{ICache: cache, softTTL: time.Minute}
+	for _, opt := range opts {
+		opt(s)
+	}
+	// value is decoded with codec of wrapped client
+	switch c := cache.(type) {
+	case *Redis:
+		s.codec = c.codec
+	case *Memcached:
+		s.codec = c.codec
+	case *Tiered:
+		s.codec = c.codec
+	}
+	return s
+}
+
+// GetOrSet unmarshal cached value of key into dest, stale value is refreshed in background with ctx values but
+// without its deadline. on miss it behave like Redis.GetOrSet
+func (s *Stale) GetOrSet(ctx context.Context, key string, ttl time.Duration, dest interface{}, loader Loader) error {
+	codec := codecFrom(ctx, s.codec)
+	storedAt, data, err := parseStale(s.ICache.Get(ctx, key))
+	if err == nil {
+		if softTTL := s.ruleTTL(key); softTTL > 0 && time.Since(storedAt) > softTTL {
+			s.loads.DoChan(key, func() (interface{}, error) {
+				data, err := s.load(detachedContext{ctx}, codec, key, ttl, loader)
+				if err != nil {
+					log.WithFields(log.Fields{"key": key, "error": err.Error()}).Error("Failed to refresh stale cached value")
+				}
+				return data, err
+			})
+		}
+		if err = codec.Unmarshal(data, dest); err == nil {
+			return nil
+		}
+	}
+	if err != ErrorNil {
+		log.WithFields(log.Fields{"key": key, "error": err.Error()}).Error("Failed to get cached value, loading it")
+	}
+
+	loaded, err, _ := s.loads.Do(key, func() (interface{}, error) {
+		return s.load(ctx, codec, key, ttl, loader)
+	})
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(loaded.([]byte), dest)
+}
+
+// load store value of loader with current time, failed write is only logged
+func (s *Stale) load(ctx context.Context, codec Codec, key string, ttl time.Duration, loader Loader) ([]byte, error) {
+	value, err := loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := make([]byte, staleHeader+len(data))
+	binary.BigEndian.PutUint64(entry, uint64(time.Now().UnixNano()))
+	copy(entry[staleHeader:], data)
+	if err := s.ICache.SetTTL(ctx, key, entry, ttl).Error(); err != nil {
+		log.WithFields(log.Fields{"key": key, "error": err.Error()}).Error("Failed to store loaded value")
+	}
+	return data, nil
+}
+
+// ruleTTL soft ttl of the first rule matching key
+func (s *Stale) ruleTTL(key string) time.Duration {
+	for _, rule := range s.rules {
+		if ok, _ := path.Match(rule.pattern, key); ok {
+			return rule.softTTL
+		}
+	}
+	return s.softTTL
+}
+
+// parseStale split entry stored by Stale.load
+func parseStale(reply IReply) (time.Time, []byte, error) {
+	entry, err := reply.Bytes()
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if len(entry) < staleHeader {
+		return time.Time{}, nil, errStaleEntry
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(entry))), entry[staleHeader:], nil
+}
+
+// detachedContext keep values of parent but is never canceled, used by background refresh which outlive request
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
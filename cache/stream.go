@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+type StreamMessage struct {
+	ID     string
+	Values map[string]string
+}
+
+// XAdd append values to stream with auto generated id, values is flattened like HSet,
+// maxLen > 0 trim stream to approximately maxLen entries
+func (r *Redis) XAdd(ctx context.Context, stream string, maxLen int, values interface{}) IReply {
+	args := redis.Args{}.Add(stream)
+	if maxLen > 0 {
+		args = args.Add("MAXLEN", "~", maxLen)
+	}
+	return r.Do(ctx, "XADD", args.Add("*").AddFlat(values)...)
+}
+
+// XGroupCreate create consumer group reading stream from start id ($ for new messages only, 0 for whole stream),
+// stream is created when missing and existing group is not an error
+func (r *Redis) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	err := r.Do(ctx, "XGROUP", "CREATE", stream, group, start, "MKSTREAM").Error()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// XReadGroup read up to count new messages delivered to consumer, block > 0 wait that long for new message,
+// no message is returned as empty slice
+func (r *Redis) XReadGroup(ctx context.Context, stream, group, consumer string, count int, block time.Duration) ([]StreamMessage, error) {
+	args := redis.Args{}.Add("GROUP", group, consumer)
+	if count > 0 {
+		args = args.Add("COUNT", count)
+	}
+	if block > 0 {
+		args = args.Add("BLOCK", block.Milliseconds())
+	}
+	args = args.Add("STREAMS", stream, ">")
+
+	reply := r.doBlocking(ctx, block, "XREADGROUP", args...)
+	streams, err := redis.Values(reply.result, reply.error)
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(streams) == 0 {
+		return nil, nil
+	}
+
+	// [[stream, [message...]]], only one stream is read
+	fields, err := redis.Values(streams[0], nil)
+	if err != nil || len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected XREADGROUP reply %v", streams[0])
+	}
+	return parseStreamMessages(fields[1])
+}
+
+// XAck acknowledge messages processed by group so they are removed from pending entries
+func (r *Redis) XAck(ctx context.Context, stream, group string, ids ...string) IReply {
+	return r.Do(ctx, "XACK", redis.Args{}.Add(stream, group).AddFlat(ids)...)
+}
+
+// XAutoClaim transfer up to count pending messages idle longer than minIdle to consumer, scanning from start id.
+// next is the start id of the following call, 0-0 when whole pending entries list was scanned
+func (r *Redis) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int) (string, []StreamMessage, error) {
+	args := redis.Args{}.Add(stream, group, consumer, minIdle.Milliseconds(), start)
+	if count > 0 {
+		args = args.Add("COUNT", count)
+	}
+
+	result := r.do(ctx, r.timeout, "XAUTOCLAIM", args)
+	reply, err := redis.Values(result.result, result.error)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(reply) < 2 {
+		return "", nil, fmt.Errorf("unexpected XAUTOCLAIM reply %v", reply)
+	}
+	next, err := redis.String(reply[0], nil)
+	if err != nil {
+		return "", nil, err
+	}
+	messages, err := parseStreamMessages(reply[1])
+	return next, messages, err
+}
+
+// parseStreamMessages parse [[id, [field, value...]]...], message deleted while pending has nil values and is skipped
+func parseStreamMessages(reply interface{}) ([]StreamMessage, error) {
+	entries, err := redis.Values(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]StreamMessage, 0, len(entries))
+	for _, entry := range entries {
+		fields, err := redis.Values(entry, nil)
+		if err != nil || len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected stream entry %v", entry)
+		}
+		id, err := redis.String(fields[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		if fields[1] == nil {
+			continue
+		}
+		values, err := redis.StringMap(fields[1], nil)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, StreamMessage{ID: id, Values: values})
+	}
+	return messages, nil
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+type StreamConsumerConfig struct {
+	Stream   string
+	Group    string
+	Consumer string
+
+	// id group start reading from when it is created, by default $ (only new messages)
+	Start string
+	// max messages read at once, by default 10
+	Count int
+	// how long read wait for new message, by default 5 seconds
+	Block time.Duration
+	// pending message idle longer than this is claimed from crashed or failing consumer, by default 1 minute
+	ClaimMinIdle time.Duration
+}
+
+// StreamHandler process message, message is acknowledged when it return nil,
+// otherwise it stay pending and is redelivered after ClaimMinIdle
+type StreamHandler func(ctx context.Context, message StreamMessage) error
+
+type StreamConsumer struct {
+	cache   ICache
+	config  StreamConsumerConfig
+	handler StreamHandler
+}
+
+// retryInterval wait between failing stream read
+const retryInterval = time.Second
+
+func NewStreamConsumer(cache ICache, config StreamConsumerConfig, handler StreamHandler) *StreamConsumer {
+	if config.Start == "" {
+		config.Start = "$"
+	}
+	if config.Count <= 0 {
+		config.Count = 10
+	}
+	if config.Block <= 0 {
+		config.Block = 5 * time.Second
+	}
+	if config.ClaimMinIdle <= 0 {
+		config.ClaimMinIdle = time.Minute
+	}
+	return &StreamConsumer{cache: cache, config: config, handler: handler}
+}
+
+// Run create consumer group then handle new and claimed pending messages until ctx is done,
+// pending messages are claimed on start and every ClaimMinIdle
+func (c *StreamConsumer) Run(ctx context.Context) error {
+	if err := c.cache.XGroupCreate(ctx, c.config.Stream, c.config.Group, c.config.Start); err != nil {
+		return err
+	}
+
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= c.config.ClaimMinIdle {
+			c.claim(ctx)
+			lastClaim = time.Now()
+		}
+
+		messages, err := c.cache.XReadGroup(ctx, c.config.Stream, c.config.Group, c.config.Consumer, c.config.Count, c.config.Block)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			c.logger().WithField("error", err.Error()).Error("Failed to read stream")
+			c.wait(ctx, retryInterval)
+			continue
+		}
+		c.handle(ctx, messages)
+	}
+	return nil
+}
+
+// claim take over pending messages idle longer than ClaimMinIdle and handle them
+func (c *StreamConsumer) claim(ctx context.Context) {
+	start := "0-0"
+	for ctx.Err() == nil {
+		next, messages, err := c.cache.XAutoClaim(ctx, c.config.Stream, c.config.Group, c.config.Consumer,
+			c.config.ClaimMinIdle, start, c.config.Count)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.logger().WithField("error", err.Error()).Error("Failed to claim pending stream messages")
+			}
+			return
+		}
+		c.handle(ctx, messages)
+		if next == "0-0" || next == "" {
+			return
+		}
+		start = next
+	}
+}
+
+func (c *StreamConsumer) handle(ctx context.Context, messages []StreamMessage) {
+	for _, message := range messages {
+		if err := c.handler(ctx, message); err != nil {
+			c.logger().WithFields(log.Fields{"id": message.ID, "error": err.Error()}).Error("Failed to handle stream message")
+			continue
+		}
+		if err := c.cache.XAck(ctx, c.config.Stream, c.config.Group, message.ID).Error(); err != nil {
+			c.logger().WithFields(log.Fields{"id": message.ID, "error": err.Error()}).Error("Failed to ack stream message")
+		}
+	}
+}
+
+func (c *StreamConsumer) wait(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+func (c *StreamConsumer) logger() log.ILogger {
+	return log.WithFields(log.Fields{"stream": c.config.Stream, "group": c.config.Group, "consumer": c.config.Consumer})
+}
@@ -0,0 +1,279 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/localcache"
+	"github.com/vincentwijaya/go-pkg/v1/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// LocalCache in-process cache in front of remote cache of Tiered, eg: localcache.New[string, []byte] with MaxSize
+// and LRU eviction
+type LocalCache = localcache.Cache[string, []byte]
+
+// TieredConsistency how local copy of other instances is kept in sync after write
+type TieredConsistency int
+
+const (
+	// TieredInvalidate publish written keys so every instance drop its local copy, stale read is limited to
+	// pub/sub delay
+	TieredInvalidate TieredConsistency = iota
+	// TieredTTL only drop local copy of the writing instance, other instances may read stale value until local ttl
+	TieredTTL
+)
+
+// Tiered cache value of Get in local cache for a short ttl in front of remote ICache. other commands go to remote,
+// string writes drop the local copy. commands sent with Do, Pipeline or Tx bypass local cache so key written that
+// way must be dropped with Invalidate. local copy is not dropped when remote key expire, so local ttl should be
+// shorter than remote expire
+type Tiered struct {
+	ICache
+	local       LocalCache
+	ttl         time.Duration
+	consistency TieredConsistency
+	channel     string
+	codec       Codec
+	cancel      context.CancelFunc
+
+	// version is bumped by every invalidation so Get does not store value loaded before it
+	mu      sync.Mutex
+	version uint64
+
+	loads singleflight.Group
+}
+
+type TieredOption func(t *Tiered)
+
+// WithTieredTTL ttl of local copy, by default 10 seconds
+func WithTieredTTL(ttl time.Duration) TieredOption {
+	return func(t *Tiered) {
+		t.ttl = ttl
+	}
+}
+
+// WithTieredConsistency by default TieredInvalidate
+func WithTieredConsistency(consistency TieredConsistency) TieredOption {
+	return func(t *Tiered) {
+		t.consistency = consistency
+	}
+}
+
+// WithTieredChannel pub/sub channel of invalidation, it is prefixed with KeyPrefix of remote redis.
+// by default tiered:invalidate
+func WithTieredChannel(channel string) TieredOption {
+	return func(t *Tiered) {
+		t.channel = channel
+	}
+}
+
+// NewTiered create two-tier cache, with TieredInvalidate remote must support Subscribe and subscription is active
+// until Close
+func NewTiered(local LocalCache, remote ICache, opts ...TieredOption) (*Tiered, error) {
+	t := &Tiered{
+		ICache:      remote,
+		local:       local,
+		ttl:         10 * time.Second,
+		consistency: TieredInvalidate,
+		channel:     "tiered:invalidate",
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	// local value is returned with codec of remote client
+	switch c := remote.(type) {
+	case *Redis:
+		t.codec, t.channel = c.codec, c.prefix+t.channel
+	case *Memcached:
+		t.codec = c.codec
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	if t.consistency == TieredInvalidate {
+		messages, err := remote.Subscribe(ctx, t.channel)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		go t.receive(messages)
+	}
+	return t, nil
+}
+
+// Close stop invalidation subscription and local cache, remote is left open
+func (t *Tiered) Close() {
+	t.cancel()
+	t.local.Close()
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) IReply {
+	if value, ok := t.local.Get(key); ok {
+		return &Reply{result: value, error: nil, codec: codecFrom(ctx, t.codec)}
+	}
+
+	t.mu.Lock()
+	version := t.version
+	t.mu.Unlock()
+
+	reply := t.ICache.Get(ctx, key)
+	if rp, ok := reply.(*Reply); ok && rp.error == nil {
+		if value, ok := rp.result.([]byte); ok {
+			t.mu.Lock()
+			if version == t.version {
+				t.local.SetWithTTL(key, value, t.ttl)
+			}
+			t.mu.Unlock()
+		}
+	}
+	return reply
+}
+
+// GetOrSet read local copy first, see Redis.GetOrSet
+func (t *Tiered) GetOrSet(ctx context.Context, key string, ttl time.Duration, dest interface{}, loader Loader) error {
+	return getOrSet(ctx, t, &t.loads, codecFrom(ctx, t.codec), key, ttl, dest, loader)
+}
+
+// Invalidate drop local copy of keys on every instance, no key drop every local copy
+func (t *Tiered) Invalidate(ctx context.Context, keys ...string) {
+	t.drop(keys)
+	if t.consistency != TieredInvalidate {
+		return
+	}
+
+	message, _ := json.Marshal(keys)
+	if err := t.ICache.Publish(ctx, t.channel, message).Error(); err != nil {
+		log.WithFields(log.Fields{"channel": t.channel, "error": err.Error()}).Error("Failed to publish tiered cache invalidation")
+	}
+}
+
+func (t *Tiered) drop(keys []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.version++
+	if len(keys) == 0 {
+		t.local.Purge()
+		return
+	}
+	for _, key := range keys {
+		t.local.Delete(key)
+	}
+}
+
+// receive drop keys published by Invalidate of every instance, message is JSON array of keys
+func (t *Tiered) receive(messages <-chan PubSubMessage) {
+	for message := range messages {
+		var keys []string
+		if err := json.Unmarshal(message.Data, &keys); err != nil {
+			log.WithFields(log.Fields{"channel": t.channel, "error": err.Error()}).Error("Failed to parse tiered cache invalidation")
+			continue
+		}
+		t.drop(keys)
+	}
+}
+
+// write drop local copy of keys after remote write
+func (t *Tiered) write(ctx context.Context, reply IReply, keys ...string) IReply {
+	if len(keys) > 0 {
+		t.Invalidate(ctx, keys...)
+	}
+	return reply
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value interface{}) IReply {
+	return t.write(ctx, t.ICache.Set(ctx, key, value), key)
+}
+
+func (t *Tiered) SetWithExpire(ctx context.Context, key string, expire int, value interface{}) IReply {
+	return t.write(ctx, t.ICache.SetWithExpire(ctx, key, expire, value), key)
+}
+
+func (t *Tiered) SetNoExpire(ctx context.Context, key string, value interface{}) IReply {
+	return t.write(ctx, t.ICache.SetNoExpire(ctx, key, value), key)
+}
+
+func (t *Tiered) SetTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) IReply {
+	return t.write(ctx, t.ICache.SetTTL(ctx, key, value, ttl), key)
+}
+
+func (t *Tiered) SetOpts(ctx context.Context, key string, value interface{}, opts SetOptions) IReply {
+	return t.write(ctx, t.ICache.SetOpts(ctx, key, value, opts), key)
+}
+
+func (t *Tiered) SetStruct(ctx context.Context, key string, value interface{}) IReply {
+	return t.write(ctx, t.ICache.SetStruct(ctx, key, value), key)
+}
+
+func (t *Tiered) SetStructWithExpire(ctx context.Context, key string, expire int, value interface{}) IReply {
+	return t.write(ctx, t.ICache.SetStructWithExpire(ctx, key, expire, value), key)
+}
+
+func (t *Tiered) SetStructNoExpire(ctx context.Context, key string, value interface{}) IReply {
+	return t.write(ctx, t.ICache.SetStructNoExpire(ctx, key, value), key)
+}
+
+func (t *Tiered) SetStructTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) IReply {
+	return t.write(ctx, t.ICache.SetStructTTL(ctx, key, value, ttl), key)
+}
+
+func (t *Tiered) GetDel(ctx context.Context, key string) IReply {
+	return t.write(ctx, t.ICache.GetDel(ctx, key), key)
+}
+
+func (t *Tiered) GetSet(ctx context.Context, key string, value interface{}) IReply {
+	return t.write(ctx, t.ICache.GetSet(ctx, key, value), key)
+}
+
+func (t *Tiered) Del(ctx context.Context, key string) IReply {
+	return t.write(ctx, t.ICache.Del(ctx, key), key)
+}
+
+// DelByPattern drop every local copy
+func (t *Tiered) DelByPattern(ctx context.Context, pattern string) (int64, error) {
+	deleted, err := t.ICache.DelByPattern(ctx, pattern)
+	t.Invalidate(ctx)
+	return deleted, err
+}
+
+func (t *Tiered) MSet(ctx context.Context, pairs map[string]interface{}) IReply {
+	return t.write(ctx, t.ICache.MSet(ctx, pairs), mapKeys(pairs)...)
+}
+
+func (t *Tiered) MSetStruct(ctx context.Context, pairs map[string]interface{}) IReply {
+	return t.write(ctx, t.ICache.MSetStruct(ctx, pairs), mapKeys(pairs)...)
+}
+
+func (t *Tiered) Incr(ctx context.Context, key string) IReply {
+	return t.write(ctx, t.ICache.Incr(ctx, key), key)
+}
+
+func (t *Tiered) IncrBy(ctx context.Context, key string, incr int) IReply {
+	return t.write(ctx, t.ICache.IncrBy(ctx, key, incr), key)
+}
+
+func (t *Tiered) Decr(ctx context.Context, key string) IReply {
+	return t.write(ctx, t.ICache.Decr(ctx, key), key)
+}
+
+func (t *Tiered) DecrBy(ctx context.Context, key string, decr int) IReply {
+	return t.write(ctx, t.ICache.DecrBy(ctx, key, decr), key)
+}
+
+func (t *Tiered) Expire(ctx context.Context, key string, expire int) IReply {
+	return t.write(ctx, t.ICache.Expire(ctx, key, expire), key)
+}
+
+func (t *Tiered) ExpireIn(ctx context.Context, key string, ttl time.Duration) IReply {
+	return t.write(ctx, t.ICache.ExpireIn(ctx, key, ttl), key)
+}
+
+func mapKeys(pairs map[string]interface{}) []string {
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	return keys
+}
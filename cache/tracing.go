@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/vincentwijaya/go-pkg/v1/cache"
+
+type tracingHook struct {
+	tracer  trace.Tracer
+	hashKey bool
+}
+
+// NewTracingHook create hook starting span per command as child of span in the command context, span has command,
+// first key (SHA-256 of it when hashKey is set, eg: key contain email) and response size in bytes
+func NewTracingHook(tracer trace.Tracer, hashKey bool) Hook {
+	return &tracingHook{tracer: tracer, hashKey: hashKey}
+}
+
+func (h *tracingHook) BeforeCommand(ctx context.Context, command string, args []interface{}) context.Context {
+	command = strings.ToUpper(command)
+	attributes := []attribute.KeyValue{
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", command),
+	}
+	if indexes := commandKeyIndexes(command, args); len(indexes) > 0 {
+		key := keyString(args[indexes[0]])
+		if h.hashKey {
+			sum := sha256.Sum256([]byte(key))
+			key = hex.EncodeToString(sum[:])
+		}
+		attributes = append(attributes, attribute.String("db.redis.key", key))
+	}
+
+	ctx, _ = h.tracer.Start(ctx, "redis."+command,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	return ctx
+}
+
+func (h *tracingHook) AfterCommand(ctx context.Context, command string, args []interface{}, reply interface{}, err error, duration time.Duration) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.Int("db.redis.response_size", replySize(reply)))
+	}
+	span.End()
+}
+
+// replySize total length of bulk strings in reply
+func replySize(reply interface{}) int {
+	switch v := reply.(type) {
+	case []byte:
+		return len(v)
+	case string:
+		return len(v)
+	case []interface{}:
+		size := 0
+		for _, value := range v {
+			size += replySize(value)
+		}
+		return size
+	}
+	return 0
+}
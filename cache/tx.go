@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// ITx is redis connection of Tx, commands are run on the same connection
+type ITx interface {
+	// Watch keys, EXEC is aborted with ErrTxAborted when one of them is modified before it
+	Watch(keys ...string) error
+	// Do run command immediately, eg: read watched key before queueing the write
+	Do(command string, args ...interface{}) IReply
+	// Queue command to be run atomically between MULTI and EXEC
+	Queue(command string, args ...interface{})
+}
+
+type transaction struct {
+	timeout time.Duration
+	// open connection to node owning slot of key, key is empty for keyless command
+	open func(slot int, key string) (redis.ConnWithTimeout, error)
+	conn redis.ConnWithTimeout
+	slot int
+	key  string
+	// keys are only checked to share one slot in cluster mode
+	cluster bool
+	queued  []pipelineCommand
+	codec   Codec
+	prefix  func(command string, args []interface{}) []interface{}
+}
+
+// ErrTxAborted watched key was modified, transaction can be retried
+var ErrTxAborted = errors.New("transaction aborted, watched key was modified")
+
+// Tx run commands queued by fn in MULTI/EXEC, reply of EXEC contains reply of every queued command.
+// WATCH is released when fn fail or EXEC finish, in cluster mode every key must hash to the same slot
+func (r *Redis) Tx(ctx context.Context, fn func(tx ITx) error) IReply {
+	timeout, err := commandTimeout(ctx, r.timeout)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+
+	codec := codecFrom(ctx, r.codec)
+	result, err := withContext(ctx, func() (interface{}, error) {
+		open := func(slot int, key string) (redis.ConnWithTimeout, error) {
+			if r.client != nil {
+				return r.goRedisTxConn(ctx, key)
+			}
+			if r.cluster == nil {
+				return r.getConnection(ctx)
+			}
+			conn, err := r.cluster.pool(r.cluster.nodeAddr(slot)).GetContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return conn.(redis.ConnWithTimeout), nil
+		}
+		t := &transaction{timeout: timeout, slot: -1, cluster: r.clustered(), codec: codec,
+			prefix: r.prefixArgs, open: open}
+		defer t.close()
+
+		if err := fn(t); err != nil {
+			return nil, err
+		}
+		return t.exec()
+	})
+	return &Reply{result: result, error: err, codec: codec}
+}
+
+// connFor open connection on first use, connection is opened to node owning the first key in cluster mode
+// so later keys must hash to the same slot
+func (t *transaction) connFor(command string, args []interface{}) (redis.ConnWithTimeout, error) {
+	if t.cluster {
+		slot, err := commandSlot(command, args)
+		if err != nil {
+			return nil, err
+		}
+		if slot >= 0 {
+			if t.slot >= 0 && t.slot != slot {
+				return nil, ErrCrossSlot
+			}
+			if t.slot < 0 {
+				t.key = keyString(commandKeys(strings.ToUpper(command), args)[0])
+			}
+			t.slot = slot
+		}
+	}
+
+	var err error
+	if t.conn == nil {
+		if t.conn, err = t.open(t.slot, t.key); err != nil {
+			return nil, err
+		}
+	}
+	return t.conn, nil
+}
+
+func (t *transaction) Watch(keys ...string) error {
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+	return t.Do("WATCH", args...).Error()
+}
+
+func (t *transaction) Do(command string, args ...interface{}) IReply {
+	args = t.prefix(command, args)
+	conn, err := t.connFor(command, args)
+	if err != nil {
+		return &Reply{result: nil, error: err}
+	}
+	result, err := conn.DoWithTimeout(t.timeout, command, args...)
+	return &Reply{result: result, error: err, codec: t.codec}
+}
+
+func (t *transaction) Queue(command string, args ...interface{}) {
+	t.queued = append(t.queued, pipelineCommand{name: command, args: t.prefix(command, args)})
+}
+
+func (t *transaction) exec() (interface{}, error) {
+	for _, command := range t.queued {
+		if _, err := t.connFor(command.name, command.args); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := t.connFor("EXEC", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = conn.Send("MULTI"); err != nil {
+		return nil, err
+	}
+	for _, command := range t.queued {
+		if err = conn.Send(command.name, command.args...); err != nil {
+			return nil, err
+		}
+	}
+	result, err := conn.DoWithTimeout(t.timeout, "EXEC")
+	if err == nil && result == nil {
+		return nil, ErrTxAborted
+	}
+	return result, err
+}
+
+// close return connection to pool, pool discard pending MULTI and release WATCH
+func (t *transaction) close() {
+	if t.conn != nil {
+		t.conn.Close()
+	}
+}
@@ -21,6 +21,11 @@ type IHttpRequestor interface {
 	NewHttpRequest(method string, uri string) IHttpRequest
 }
 
+// IResolver resolve logical service name into host:port, eg: discovery.IResolver
+type IResolver interface {
+	ResolveHost(ctx context.Context, name string) (string, error)
+}
+
 type IHttpRequest interface {
 	SetHeader(key, value string)
 	SetBody(body []byte)
@@ -45,11 +50,13 @@ type httpFile struct {
 }
 
 type HttpRequestor struct {
-	client IHttpClient
+	client   IHttpClient
+	resolver IResolver
 }
 
 type HttpRequest struct {
 	client    IHttpClient
+	resolver  IResolver
 	method    string
 	url       string
 	headers   map[string]string
@@ -73,14 +80,21 @@ func NewHttpRequestor(client IHttpClient) IHttpRequestor {
 	return &HttpRequestor{client: client}
 }
 
+// NewHttpRequestorWithResolver create requestor which resolve logical service name in url host
+// eg: http://payment/v1/charge, host without dot and port is resolved using resolver
+func NewHttpRequestorWithResolver(client IHttpClient, resolver IResolver) IHttpRequestor {
+	return &HttpRequestor{client: client, resolver: resolver}
+}
+
 func (rq *HttpRequestor) NewHttpRequest(method string, uri string) IHttpRequest {
 	return &HttpRequest{
-		client:  rq.client,
-		method:  strings.ToUpper(method),
-		url:     uri,
-		headers: map[string]string{},
-		params:  url.Values{},
-		files:   map[string]httpFile{},
+		client:   rq.client,
+		resolver: rq.resolver,
+		method:   strings.ToUpper(method),
+		url:      uri,
+		headers:  map[string]string{},
+		params:   url.Values{},
+		files:    map[string]httpFile{},
 	}
 }
 
@@ -94,6 +108,10 @@ func isValidMethod(method string) bool {
 	return false
 }
 
+func isLogicalHost(host string) bool {
+	return host != "" && host != "localhost" && !strings.ContainsAny(host, ".:[")
+}
+
 func (rq *HttpRequest) setQueryParams(u *url.URL) (*http.Request, error) {
 	if len(rq.params) != 0 {
 		query := u.Query()
@@ -200,6 +218,13 @@ func (rq *HttpRequest) Do(ctx context.Context, timeout int) (IHttpResponse, erro
 		return nil, err
 	}
 
+	if rq.resolver != nil && isLogicalHost(u.Host) {
+		u.Host, err = rq.resolver.ResolveHost(ctx, u.Host)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if !isValidMethod(rq.method) {
 		return nil, err
 	}
@@ -215,7 +240,9 @@ func (rq *HttpRequest) Do(ctx context.Context, timeout int) (IHttpResponse, erro
 	}
 
 	if timeout > 0 {
-		ctx, _ = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
 	}
 	request = request.WithContext(ctx)
 
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SelectBuilder build SELECT query with ? placeholder, every value must be passed as argument
+// so dynamic filter can not inject sql, eg:
+// Builder().Select("id", "name").From("users").Where("status = ?", s).Limit(10).SelectInto(ctx, db, &users)
+type SelectBuilder struct {
+	columns []string
+	from    string
+	joins   []clause
+	wheres  []clause
+	groupBy []string
+	having  []clause
+	orderBy []string
+	limit   int
+	offset  int
+}
+
+type clause struct {
+	sql  string
+	args []interface{}
+}
+
+var ErrBuilderNoTable = errors.New("Query builder requires From table")
+
+// Builder start new SELECT query
+func Builder() *SelectBuilder {
+	return &SelectBuilder{}
+}
+
+// Select set selected columns, by default *
+func (b *SelectBuilder) Select(columns ...string) *SelectBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = table
+	return b
+}
+
+// Join add join clause, eg: Join("JOIN roles r ON r.id = u.role_id")
+func (b *SelectBuilder) Join(join string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, clause{sql: join, args: args})
+	return b
+}
+
+// Where add condition combined with AND, slice argument is expanded for IN (?)
+func (b *SelectBuilder) Where(condition string, args ...interface{}) *SelectBuilder {
+	b.wheres = append(b.wheres, clause{sql: condition, args: args})
+	return b
+}
+
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// Having add group condition combined with AND
+func (b *SelectBuilder) Having(condition string, args ...interface{}) *SelectBuilder {
+	b.having = append(b.having, clause{sql: condition, args: args})
+	return b
+}
+
+// OrderBy add order expression, eg: OrderBy("created_at DESC", "id")
+func (b *SelectBuilder) OrderBy(orders ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, orders...)
+	return b
+}
+
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+func (b *SelectBuilder) Offset(offset int) *SelectBuilder {
+	b.offset = offset
+	return b
+}
+
+// ToSQL render query with ? placeholder, use DB.Rebind before running it on postgres
+func (b *SelectBuilder) ToSQL() (string, []interface{}, error) {
+	if b.from == "" {
+		return "", nil, ErrBuilderNoTable
+	}
+
+	var sql strings.Builder
+	var args []interface{}
+
+	columns := "*"
+	if len(b.columns) > 0 {
+		columns = strings.Join(b.columns, ", ")
+	}
+	sql.WriteString("SELECT " + columns + " FROM " + b.from)
+
+	for _, join := range b.joins {
+		sql.WriteString(" " + join.sql)
+		args = append(args, join.args...)
+	}
+
+	args = writeConditions(&sql, " WHERE ", b.wheres, args)
+
+	if len(b.groupBy) > 0 {
+		sql.WriteString(" GROUP BY " + strings.Join(b.groupBy, ", "))
+	}
+
+	args = writeConditions(&sql, " HAVING ", b.having, args)
+
+	if len(b.orderBy) > 0 {
+		sql.WriteString(" ORDER BY " + strings.Join(b.orderBy, ", "))
+	}
+	if b.limit > 0 {
+		sql.WriteString(" LIMIT " + strconv.Itoa(b.limit))
+	}
+	if b.offset > 0 {
+		sql.WriteString(" OFFSET " + strconv.Itoa(b.offset))
+	}
+
+	return sqlx.In(sql.String(), args...)
+}
+
+// writeConditions join conditions with AND, each condition is wrapped so OR inside it keeps its precedence
+func writeConditions(sql *strings.Builder, keyword string, conditions []clause, args []interface{}) []interface{} {
+	if len(conditions) == 0 {
+		return args
+	}
+
+	parts := make([]string, len(conditions))
+	for i, condition := range conditions {
+		parts[i] = "(" + condition.sql + ")"
+		args = append(args, condition.args...)
+	}
+	sql.WriteString(keyword + strings.Join(parts, " AND "))
+	return args
+}
+
+// Build render query with bindvar of db driver
+func (b *SelectBuilder) Build(db DB) (string, []interface{}, error) {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return "", nil, err
+	}
+	return db.Rebind(query), args, nil
+}
+
+// SelectInto run query with db.Select
+func (b *SelectBuilder) SelectInto(ctx context.Context, db DB, dest interface{}) error {
+	query, args, err := b.Build(db)
+	if err != nil {
+		return err
+	}
+	return db.Select(ctx, dest, query, args...)
+}
+
+// GetInto run query with db.Get
+func (b *SelectBuilder) GetInto(ctx context.Context, db DB, dest interface{}) error {
+	query, args, err := b.Build(db)
+	if err != nil {
+		return err
+	}
+	return db.Get(ctx, dest, query, args...)
+}
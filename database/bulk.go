@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type BulkOption func(*bulkOptions)
+
+type execFunc func(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+
+type bulkOptions struct {
+	batchSize     int
+	inTransaction bool
+}
+
+// maxPlaceholders postgres and mysql limit of bind parameters in one statement
+const maxPlaceholders = 65535
+
+// bulkLimits return limit of bind parameters and VALUES rows in one statement of driver, 0 rows is unlimited
+func bulkLimits(driver string) (placeholders, rows int) {
+	switch {
+	case isSQLServer(driver):
+		return 2100, 1000
+	case driver == "sqlite3":
+		return 32766, 0
+	}
+	return maxPlaceholders, 0
+}
+
+var ErrBulkEmptyColumns = errors.New("Bulk insert row has no db tagged field")
+
+// WithBatchSize set number of rows per INSERT statement, by default 500
+// batch size is reduced automatically when rows * columns exceed bind parameters limit of driver,
+// eg: 2100 parameters and 1000 rows on sqlserver
+func WithBatchSize(size int) BulkOption {
+	return func(o *bulkOptions) {
+		o.batchSize = size
+	}
+}
+
+// InTransaction run every batch inside one transaction, so either all rows or none are inserted
+func InTransaction() BulkOption {
+	return func(o *bulkOptions) {
+		o.inTransaction = true
+	}
+}
+
+// BulkInsert insert rows with multi-row INSERT statements, columns are taken from struct db tags
+// all rows must have the same struct type
+func (db *Database) BulkInsert(ctx context.Context, table string, rows []interface{}, opts ...BulkOption) (int64, error) {
+	options := newBulkOptions(opts)
+	if !options.inTransaction {
+		return bulkInsert(ctx, db.connection, db.Exec, table, rows, options)
+	}
+
+	var affected int64
+	err := db.WithTransaction(ctx, func(tx Tx) error {
+		var err error
+		affected, err = tx.BulkInsert(ctx, table, rows, WithBatchSize(options.batchSize))
+		return err
+	})
+	return affected, err
+}
+
+// BulkInsert insert rows inside the transaction, see Database.BulkInsert
+func (tx *DBTransaction) BulkInsert(ctx context.Context, table string, rows []interface{}, opts ...BulkOption) (int64, error) {
+	return bulkInsert(ctx, tx.connection, tx.Exec, table, rows, newBulkOptions(opts))
+}
+
+func newBulkOptions(opts []BulkOption) bulkOptions {
+	options := bulkOptions{batchSize: 500}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.batchSize <= 0 {
+		options.batchSize = 500
+	}
+	return options
+}
+
+func bulkInsert(ctx context.Context, binder *sqlx.DB, exec execFunc, table string, rows []interface{}, options bulkOptions) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	rowType := indirectType(reflect.TypeOf(rows[0]))
+	columns, fields := structColumns(rowType)
+	if len(columns) == 0 {
+		return 0, ErrBulkEmptyColumns
+	}
+
+	batchSize := options.batchSize
+	placeholders, maxRows := bulkLimits(binder.DriverName())
+	if batchSize*len(columns) > placeholders {
+		batchSize = placeholders / len(columns)
+	}
+	if maxRows > 0 && batchSize > maxRows {
+		batchSize = maxRows
+	}
+	if batchSize == 0 {
+		return 0, fmt.Errorf("Bulk insert row has %d columns, more than %d bind parameters allowed by %s", len(columns), placeholders, binder.DriverName())
+	}
+
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+
+	var affected int64
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for _, row := range rows[start:end] {
+			v := reflect.Indirect(reflect.ValueOf(row))
+			if v.Type() != rowType {
+				return affected, fmt.Errorf("Bulk insert rows must have the same type, got %s and %s", rowType, v.Type())
+			}
+			for _, index := range fields {
+				args = append(args, v.FieldByIndex(index).Interface())
+			}
+			values = append(values, placeholder)
+		}
+
+		query := binder.Rebind(prefix + strings.Join(values, ", "))
+		result, err := exec(ctx, query, args...)
+		if err != nil {
+			return affected, err
+		}
+		if count, err := result.RowsAffected(); err == nil {
+			affected += count
+		}
+	}
+	return affected, nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// structColumns return db tagged columns and their field index, embedded structs are flattened
+func structColumns(t reflect.Type) ([]string, [][]int) {
+	var columns []string
+	var fields [][]int
+	if t.Kind() != reflect.Struct {
+		return columns, fields
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("db"), ",")[0]
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			embeddedColumns, embeddedFields := structColumns(field.Type)
+			for j := range embeddedColumns {
+				columns = append(columns, embeddedColumns[j])
+				fields = append(fields, append([]int{i}, embeddedFields[j]...))
+			}
+			continue
+		}
+
+		if tag == "" {
+			continue
+		}
+		columns = append(columns, tag)
+		fields = append(fields, []int{i})
+	}
+	return columns, fields
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrClosed = errors.New("Database is closed")
+
+// poolState is shared by Database and its WithTimeout copies
+type poolState struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+}
+
+// acquire register in-flight call, release must be called once the call is done
+func (db *Database) acquire() (release func(), err error) {
+	db.state.mu.Lock()
+	defer db.state.mu.Unlock()
+	if db.state.closed {
+		return nil, ErrClosed
+	}
+	db.state.inflight.Add(1)
+	return db.state.inflight.Done, nil
+}
+
+// Close stop accepting new queries and transactions, wait for in-flight queries and open transactions
+// until ctx is done and then close the pool, eg: on SIGTERM. ctx error is returned when draining is cut short.
+// rows of Queryx are waited until they are closed and prepared statements until they are closed,
+// row of NamedQueryRowx is waited until the query is sent
+func (db *Database) Close(ctx context.Context) error {
+	db.state.mu.Lock()
+	if db.state.closed {
+		db.state.mu.Unlock()
+		return ErrClosed
+	}
+	db.state.closed = true
+	db.state.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		db.state.inflight.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if db.stmts != nil {
+		db.stmts.close()
+	}
+	if db.metricsName != "" {
+		defaultCollector.remove(db.metricsName, db.connection)
+	}
+	if closeErr := db.connection.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// inflightRows release in-flight registration once rows are closed or iterated to the end
+type inflightRows struct {
+	Rows
+	release     func()
+	releaseOnce sync.Once
+}
+
+func (r *inflightRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.releaseOnce.Do(r.release)
+	return false
+}
+
+func (r *inflightRows) Close() error {
+	defer r.releaseOnce.Do(r.release)
+	return r.Rows.Close()
+}
+
+// Close stop health check and close primary and replicas, see Database.Close
+func (rs *ReplicaSet) Close(ctx context.Context) error {
+	rs.closeOnce.Do(func() {
+		close(rs.done)
+	})
+
+	errs := make([]error, len(rs.replicas)+1)
+	var wg sync.WaitGroup
+	for i, r := range rs.replicas {
+		wg.Add(1)
+		go func(i int, db *Database) {
+			defer wg.Done()
+			errs[i] = db.Close(ctx)
+		}(i, r.db)
+	}
+	errs[len(rs.replicas)] = rs.Database.Close(ctx)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// DialFunc open network connection to database server, eg: through SSH or SOCKS bastion
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+type pqDialer struct {
+	dial DialFunc
+}
+
+const mysqlRegistrationName = "go-pkg-database-"
+
+var (
+	ErrTLSNotSupported  = errors.New("Config TLS is only supported by mysql driver, use sslmode, sslrootcert, sslcert and sslkey DSN params for postgres")
+	ErrDialNotSupported = errors.New("Config Dial is only supported by mysql and postgres driver")
+
+	mysqlRegistrationSeq uint64
+)
+
+// connect open connection pool of driver, custom TLS, dialer and connection hooks are applied through driver connector
+func connect(cfg Config, dsn string) (*sqlx.DB, error) {
+	if cfg.TLS == nil && cfg.Dial == nil && !cfg.ConnHooks.enabled() && cfg.CredentialFunc == nil {
+		return sqlx.Open(cfg.Driver, dsn)
+	}
+
+	var connector driver.Connector
+	var err error
+	if cfg.CredentialFunc != nil {
+		connector, err = newCredentialConnector(cfg, dsn)
+	} else {
+		connector, err = newConnector(cfg, dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ConnHooks.enabled() {
+		connector = &hookedConnector{Connector: connector, hooks: cfg.ConnHooks}
+	}
+	return sqlx.NewDb(sql.OpenDB(connector), cfg.Driver), nil
+}
+
+func newConnector(cfg Config, dsn string) (driver.Connector, error) {
+	if cfg.TLS == nil && cfg.Dial == nil {
+		return openConnector(cfg.Driver, dsn)
+	}
+
+	switch cfg.Driver {
+	case "mysql":
+		mysqlCfg, err := mysqlConfig(cfg, dsn)
+		if err != nil {
+			return nil, err
+		}
+		return mysql.NewConnector(mysqlCfg)
+	case "postgres":
+		if cfg.TLS != nil {
+			return nil, ErrTLSNotSupported
+		}
+		connector, err := pq.NewConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		connector.Dialer(&pqDialer{dial: cfg.Dial})
+		return connector, nil
+	}
+
+	if cfg.Dial != nil {
+		return nil, ErrDialNotSupported
+	}
+	return nil, ErrTLSNotSupported
+}
+
+// mysqlConfig register TLS config and dialer under unique name, mysql driver only resolve them by name
+func mysqlConfig(cfg Config, dsn string) (*mysql.Config, error) {
+	mysqlCfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	name := mysqlRegistrationName + strconv.FormatUint(atomic.AddUint64(&mysqlRegistrationSeq, 1), 10)
+	if cfg.TLS != nil {
+		if err = mysql.RegisterTLSConfig(name, cfg.TLS.Clone()); err != nil {
+			return nil, err
+		}
+		mysqlCfg.TLSConfig = name
+	}
+	if cfg.Dial != nil {
+		network := mysqlCfg.Net
+		mysql.RegisterDialContext(name, func(ctx context.Context, address string) (net.Conn, error) {
+			return cfg.Dial(ctx, network, address)
+		})
+		mysqlCfg.Net = name
+	}
+
+	return mysqlCfg, nil
+}
+
+func (d *pqDialer) Dial(network, address string) (net.Conn, error) {
+	return d.dial(context.Background(), network, address)
+}
+
+func (d *pqDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.dial(ctx, network, address)
+}
+
+func (d *pqDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d.dial(ctx, network, address)
+}
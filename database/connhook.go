@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// ConnHooks observe lifecycle of physical connections in the pool
+type ConnHooks struct {
+	// called on every new connection before it is used, eg: SET search_path or SET time_zone,
+	// returned error discard the connection
+	OnConnect func(ctx context.Context, conn SessionConn) error
+
+	// called before idle connection is reused from the pool
+	OnReset func(ctx context.Context)
+
+	// called when connection is closed, eg: lifetime expired, pool shrinks or connection is broken
+	OnClose func()
+
+	// called when connection can not be opened or driver report it is broken
+	OnError func(err error)
+}
+
+// SessionConn run session setup statement on single connection
+type SessionConn interface {
+	Exec(ctx context.Context, query string) error
+}
+
+func (h ConnHooks) enabled() bool {
+	return h.OnConnect != nil || h.OnReset != nil || h.OnClose != nil || h.OnError != nil
+}
+
+func (h ConnHooks) notifyError(err error) {
+	if h.OnError != nil && err != nil && err != driver.ErrSkip {
+		h.OnError(err)
+	}
+}
+
+// dsnConnector connector of driver which does not implement driver.DriverContext, like sql.Open does
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// openConnector resolve registered driver by name
+func openConnector(driverName, dsn string) (driver.Connector, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	db.Close()
+
+	if dc, ok := drv.(driver.DriverContext); ok {
+		return dc.OpenConnector(dsn)
+	}
+	return &dsnConnector{dsn: dsn, driver: drv}, nil
+}
+
+type hookedConnector struct {
+	driver.Connector
+	hooks ConnHooks
+}
+
+func (c *hookedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		c.hooks.notifyError(err)
+		return nil, err
+	}
+
+	hooked := &hookedConn{Conn: conn, hooks: c.hooks}
+	if c.hooks.OnConnect != nil {
+		if err = c.hooks.OnConnect(ctx, sessionConn{hooked}); err != nil {
+			c.hooks.notifyError(err)
+			conn.Close()
+			return nil, err
+		}
+	}
+	return hooked, nil
+}
+
+// hookedConn forward every optional driver interface, driver.ErrSkip let database/sql fallback
+// when underlying connection does not implement it
+type hookedConn struct {
+	driver.Conn
+	hooks ConnHooks
+}
+
+func (c *hookedConn) broken(err error) error {
+	if errors.Is(err, driver.ErrBadConn) {
+		c.hooks.notifyError(err)
+	}
+	return err
+}
+
+type sessionConn struct {
+	conn *hookedConn
+}
+
+func (s sessionConn) Exec(ctx context.Context, query string) error {
+	c := s.conn
+	_, err := c.ExecContext(ctx, query, nil)
+	if err != driver.ErrSkip {
+		return err
+	}
+
+	stmt, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+func (c *hookedConn) Close() error {
+	if c.hooks.OnClose != nil {
+		c.hooks.OnClose()
+	}
+	return c.Conn.Close()
+}
+
+func (c *hookedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err := p.PrepareContext(ctx, query)
+		return stmt, c.broken(err)
+	}
+	stmt, err := c.Conn.Prepare(query)
+	return stmt, c.broken(err)
+}
+
+func (c *hookedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err := b.BeginTx(ctx, opts)
+		return tx, c.broken(err)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("Driver does not support non-default isolation level or read-only transaction")
+	}
+	tx, err := c.Conn.Begin()
+	return tx, c.broken(err)
+}
+
+func (c *hookedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		result, err := e.ExecContext(ctx, query, args)
+		return result, c.broken(err)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *hookedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		rows, err := q.QueryContext(ctx, query, args)
+		return rows, c.broken(err)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *hookedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return c.broken(p.Ping(ctx))
+	}
+	return nil
+}
+
+func (c *hookedConn) ResetSession(ctx context.Context) error {
+	if c.hooks.OnReset != nil {
+		c.hooks.OnReset(ctx)
+	}
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return c.broken(r.ResetSession(ctx))
+	}
+	return nil
+}
+
+func (c *hookedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *hookedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
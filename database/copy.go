@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// RowSource provide rows for CopyFrom, same contract as sql.Rows iteration
+type RowSource interface {
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+type rowsSource struct {
+	rows  [][]interface{}
+	index int
+}
+
+var ErrCopyNotSupported = errors.New("CopyFrom is only supported by postgres driver")
+
+// CopyFromRows create RowSource from in-memory rows
+func CopyFromRows(rows [][]interface{}) RowSource {
+	return &rowsSource{rows: rows, index: -1}
+}
+
+func (rs *rowsSource) Next() bool {
+	rs.index++
+	return rs.index < len(rs.rows)
+}
+
+func (rs *rowsSource) Values() ([]interface{}, error) {
+	return rs.rows[rs.index], nil
+}
+
+func (rs *rowsSource) Err() error {
+	return nil
+}
+
+// CopyFrom bulk load rows into table using postgres COPY protocol inside a transaction,
+// it is much faster than INSERT for large data set. Returned count is number of copied rows
+func (db *Database) CopyFrom(ctx context.Context, table string, columns []string, src RowSource) (int64, error) {
+	if db.connection.DriverName() != "postgres" {
+		return 0, ErrCopyNotSupported
+	}
+	release, err := db.acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	tx, err := db.connection.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := copyIn(ctx, tx, table, columns, src)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func copyIn(ctx context.Context, tx *sql.Tx, table string, columns []string, src RowSource) (int64, error) {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var count int64
+	for src.Next() {
+		values, err := src.Values()
+		if err != nil {
+			return count, err
+		}
+		if _, err = stmt.ExecContext(ctx, values...); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err = src.Err(); err != nil {
+		return count, err
+	}
+
+	// exec without args flush buffered rows to the server
+	_, err = stmt.ExecContext(ctx)
+	return count, err
+}
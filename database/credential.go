@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// CredentialFunc return user and password for new connection, eg: vault dynamic credential or aws iam auth token,
+// it should cache credential until it expire because it is called for every new connection
+type CredentialFunc func(ctx context.Context) (user, password string, err error)
+
+// credentialConnector open every connection with fresh credential, existing connections keep
+// their credential until they are closed, so set ConnMaxLifetimeDuration below credential lifetime
+type credentialConnector struct {
+	credentials CredentialFunc
+	open        func(user, password string) (driver.Connector, error)
+	driver      driver.Driver
+}
+
+func newCredentialConnector(cfg Config, dsn string) (driver.Connector, error) {
+	c := &credentialConnector{credentials: cfg.CredentialFunc}
+	if cfg.Driver == "mysql" {
+		mysqlCfg, err := mysqlConfig(cfg, dsn)
+		if err != nil {
+			return nil, err
+		}
+		c.open = func(user, password string) (driver.Connector, error) {
+			connCfg := mysqlCfg.Clone()
+			connCfg.User, connCfg.Passwd = user, password
+			return mysql.NewConnector(connCfg)
+		}
+	} else {
+		c.open = func(user, password string) (driver.Connector, error) {
+			credentialDSN, err := dsnWithCredentials(cfg.Driver, dsn, user, password)
+			if err != nil {
+				return nil, err
+			}
+			return newConnector(cfg, credentialDSN)
+		}
+	}
+
+	// validate DSN and resolve driver without calling credentials
+	connector, err := newConnector(cfg, dsn)
+	if err != nil {
+		return nil, err
+	}
+	c.driver = connector.Driver()
+	return c, nil
+}
+
+func (c *credentialConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	user, password, err := c.credentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get database credential. Error: %w", err)
+	}
+	connector, err := c.open(user, password)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *credentialConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// dsnWithCredentials override user and password of URL DSN or postgres key=value DSN
+func dsnWithCredentials(driverName, dsn, user, password string) (string, error) {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.User = url.UserPassword(user, password)
+		return u.String(), nil
+	}
+
+	if driverName == "postgres" {
+		// later key override earlier one
+		quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+		return fmt.Sprintf("%s user='%s' password='%s'", dsn, quote.Replace(user), quote.Replace(password)), nil
+	}
+	return "", fmt.Errorf("CredentialFunc requires URL DSN for %s driver", driverName)
+}
@@ -0,0 +1,766 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vincentwijaya/go-pkg/v1/database"
+)
+
+// Query executed query, named query and statement args are recorded as is without binding
+type Query struct {
+	SQL  string
+	Args []interface{}
+	InTx bool
+}
+
+// result implement sql.Result for stubbed exec
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+// Stub response of query matching pattern
+type Stub struct {
+	pattern *regexp.Regexp
+	value   interface{}
+	result  result
+	err     error
+}
+
+type FakeDB struct {
+	// error returned by Ping, PingContext and HealthCheck
+	PingErr error
+
+	mu        sync.Mutex
+	queries   []Query
+	stubs     []*Stub
+	txs       []*FakeTx
+	listeners map[string][]chan database.Notification
+	locks     map[string]chan struct{}
+	closed    bool
+}
+
+type FakeTx struct {
+	db         *FakeDB
+	mu         sync.Mutex
+	queries    []Query
+	committed  bool
+	rolledBack bool
+	readOnly   bool
+}
+
+type fakeStmt struct {
+	db    *FakeDB
+	tx    *FakeTx
+	query string
+}
+
+// fakeNamedStmt record arg as the only query arg
+type fakeNamedStmt struct {
+	stmt fakeStmt
+}
+
+// fakeRows iterate stubbed slice for Queryx
+type fakeRows struct {
+	items reflect.Value
+	index int
+}
+
+// TestingT is subset of testing.TB used by assertion
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// FakeDB must keep up with database.DB and database.Tx
+var (
+	_ database.DB = (*FakeDB)(nil)
+	_ database.Tx = (*FakeTx)(nil)
+)
+
+// New create fake database.DB recording every query for unit testing repositories without real database
+func New() *FakeDB {
+	return &FakeDB{}
+}
+
+// On stub every query matching regexp pattern, the latest matching stub win, eg: On(`SELECT .* FROM users`)
+// query without matching stub return empty result, Get return sql.ErrNoRows
+func (f *FakeDB) On(pattern string) *Stub {
+	stub := &Stub{pattern: regexp.MustCompile(pattern)}
+	f.mu.Lock()
+	f.stubs = append(f.stubs, stub)
+	f.mu.Unlock()
+	return stub
+}
+
+// Return set value assigned to dest of Get (struct or scalar) and Select or Queryx (slice)
+func (s *Stub) Return(value interface{}) *Stub {
+	s.value = value
+	return s
+}
+
+// ReturnResult set result of Exec
+func (s *Stub) ReturnResult(lastInsertID, rowsAffected int64) *Stub {
+	s.result = result{lastInsertID: lastInsertID, rowsAffected: rowsAffected}
+	return s
+}
+
+func (s *Stub) ReturnError(err error) *Stub {
+	s.err = err
+	return s
+}
+
+// Queries return every query executed through db and its transactions in order
+func (f *FakeDB) Queries() []Query {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Query{}, f.queries...)
+}
+
+// Transactions return every started transaction in order
+func (f *FakeDB) Transactions() []*FakeTx {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*FakeTx{}, f.txs...)
+}
+
+// AssertExecuted check a query matching pattern was executed
+func (f *FakeDB) AssertExecuted(t TestingT, pattern string) {
+	t.Helper()
+	re := regexp.MustCompile(pattern)
+	for _, q := range f.Queries() {
+		if re.MatchString(q.SQL) {
+			return
+		}
+	}
+	t.Errorf("Expected query matching %q to be executed", pattern)
+}
+
+// AssertCommitted check the last transaction was committed
+func (f *FakeDB) AssertCommitted(t TestingT) {
+	t.Helper()
+	tx := f.lastTx()
+	if tx == nil || !tx.Committed() {
+		t.Errorf("Expected last transaction to be committed")
+	}
+}
+
+// AssertRolledBack check the last transaction was rolled back
+func (f *FakeDB) AssertRolledBack(t TestingT) {
+	t.Helper()
+	tx := f.lastTx()
+	if tx == nil || !tx.RolledBack() {
+		t.Errorf("Expected last transaction to be rolled back")
+	}
+}
+
+func (f *FakeDB) lastTx() *FakeTx {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.txs) == 0 {
+		return nil
+	}
+	return f.txs[len(f.txs)-1]
+}
+
+// record save query and return its matching stub
+func (f *FakeDB) record(tx *FakeTx, query string, args []interface{}) *Stub {
+	q := Query{SQL: query, Args: args, InTx: tx != nil}
+	if tx != nil {
+		tx.mu.Lock()
+		tx.queries = append(tx.queries, q)
+		tx.mu.Unlock()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, q)
+	for i := len(f.stubs) - 1; i >= 0; i-- {
+		if f.stubs[i].pattern.MatchString(query) {
+			return f.stubs[i]
+		}
+	}
+	return &Stub{}
+}
+
+func (f *FakeDB) exec(tx *FakeTx, query string, args []interface{}) (sql.Result, error) {
+	stub := f.record(tx, query, args)
+	if stub.err != nil {
+		return nil, stub.err
+	}
+	return stub.result, nil
+}
+
+func (f *FakeDB) get(tx *FakeTx, dest interface{}, query string, args []interface{}) error {
+	stub := f.record(tx, query, args)
+	if stub.err != nil {
+		return stub.err
+	}
+	if stub.value == nil {
+		return sql.ErrNoRows
+	}
+	return assign(dest, stub.value)
+}
+
+func (f *FakeDB) selectx(tx *FakeTx, dest interface{}, query string, args []interface{}) error {
+	stub := f.record(tx, query, args)
+	if stub.err != nil || stub.value == nil {
+		return stub.err
+	}
+	return assign(dest, stub.value)
+}
+
+func (f *FakeDB) queryx(tx *FakeTx, query string, args []interface{}) (database.Rows, error) {
+	stub := f.record(tx, query, args)
+	if stub.err != nil {
+		return nil, stub.err
+	}
+	items := reflect.ValueOf(stub.value)
+	if stub.value == nil {
+		items = reflect.ValueOf([]interface{}{})
+	}
+	if items.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("Stub of Queryx must return slice, got %T", stub.value)
+	}
+	return &fakeRows{items: items, index: -1}, nil
+}
+
+func (f *FakeDB) each(tx *FakeTx, query string, args []interface{}, fn func(row database.Row) error) error {
+	rows, err := f.queryx(tx, query, args)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FakeDB) execScript(tx *FakeTx, script string) error {
+	for _, statement := range database.SplitStatements(script) {
+		if _, err := f.exec(tx, statement, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FakeDB) bulkInsert(tx *FakeTx, table string, rows []interface{}) (int64, error) {
+	stub := f.record(tx, "INSERT INTO "+table, rows)
+	if stub.err != nil {
+		return 0, stub.err
+	}
+	return int64(len(rows)), nil
+}
+
+func (f *FakeDB) upsert(tx *FakeTx, table string, obj interface{}) (sql.Result, error) {
+	return f.exec(tx, "UPSERT INTO "+table, []interface{}{obj})
+}
+
+// assign copy value into dest pointer, pointer value is dereferenced
+func assign(dest, value interface{}) error {
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() {
+		return fmt.Errorf("Destination must be non-nil pointer, got %T", dest)
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr && !v.Type().AssignableTo(d.Elem().Type()) {
+		v = v.Elem()
+	}
+	if !v.Type().AssignableTo(d.Elem().Type()) {
+		return fmt.Errorf("Stubbed value of type %s is not assignable to %s", v.Type(), d.Elem().Type())
+	}
+	d.Elem().Set(v)
+	return nil
+}
+
+func (f *FakeDB) begin() *FakeTx {
+	tx := &FakeTx{db: f}
+	f.mu.Lock()
+	f.txs = append(f.txs, tx)
+	f.mu.Unlock()
+	return tx
+}
+
+func (f *FakeDB) Ping() error {
+	return f.PingErr
+}
+
+func (f *FakeDB) PingContext(ctx context.Context) error {
+	return f.PingErr
+}
+
+func (f *FakeDB) HealthCheck(ctx context.Context) (database.HealthReport, error) {
+	return database.HealthReport{Driver: "dbtest"}, f.PingErr
+}
+
+// Rebind return query as is, recorded query keep ? placeholder
+func (f *FakeDB) Rebind(query string) string {
+	return query
+}
+
+func (f *FakeDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return f.exec(nil, query, args)
+}
+
+func (f *FakeDB) NamedExec(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return f.exec(nil, query, []interface{}{arg})
+}
+
+// NamedQueryRowx record query and return nil, *sqlx.Row can not be faked
+func (f *FakeDB) NamedQueryRowx(ctx context.Context, query string, arg interface{}) *sqlx.Row {
+	f.record(nil, query, []interface{}{arg})
+	return nil
+}
+
+func (f *FakeDB) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return f.get(nil, dest, query, args)
+}
+
+func (f *FakeDB) NamedGet(ctx context.Context, dest interface{}, query string, arg interface{}) error {
+	return f.get(nil, dest, query, []interface{}{arg})
+}
+
+func (f *FakeDB) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return f.selectx(nil, dest, query, args)
+}
+
+func (f *FakeDB) NamedSelect(ctx context.Context, dest interface{}, query string, arg interface{}) error {
+	return f.selectx(nil, dest, query, []interface{}{arg})
+}
+
+func (f *FakeDB) Queryx(ctx context.Context, query string, args ...interface{}) (database.Rows, error) {
+	return f.queryx(nil, query, args)
+}
+
+// Each stub must return slice like Queryx, fn receive every item in order
+func (f *FakeDB) Each(ctx context.Context, query string, args []interface{}, fn func(row database.Row) error) error {
+	return f.each(nil, query, args, fn)
+}
+
+// Explain record query and return empty plan
+func (f *FakeDB) Explain(ctx context.Context, query string, args ...interface{}) (database.Plan, error) {
+	stub := f.record(nil, query, args)
+	return database.Plan{Driver: "dbtest"}, stub.err
+}
+
+// Close mark f closed, queries are still served so teardown order of test does not matter
+func (f *FakeDB) Close(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return database.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
+
+// Closed report whether Close has been called
+func (f *FakeDB) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// Runner return transaction attached by database.ContextWithTx or f itself
+func (f *FakeDB) Runner(ctx context.Context) database.Runner {
+	if tx, ok := database.TxFromContext(ctx); ok {
+		return tx
+	}
+	return f
+}
+
+// WithTimeout return f itself, stubbed query never block
+func (f *FakeDB) WithTimeout(timeout time.Duration) database.DB {
+	return f
+}
+
+// ExecScript record every statement of script as separate query, opts is ignored
+func (f *FakeDB) ExecScript(ctx context.Context, script string, opts ...database.ScriptOption) error {
+	return f.execScript(nil, script)
+}
+
+// SelectMaps stub must return []map[string]interface{}
+func (f *FakeDB) SelectMaps(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	err := f.selectx(nil, &result, query, args)
+	return result, err
+}
+
+// BulkInsert record query "INSERT INTO <table>" with rows as args
+func (f *FakeDB) BulkInsert(ctx context.Context, table string, rows []interface{}, opts ...database.BulkOption) (int64, error) {
+	return f.bulkInsert(nil, table, rows)
+}
+
+// Upsert record query "UPSERT INTO <table>" with obj as arg
+func (f *FakeDB) Upsert(ctx context.Context, table string, obj interface{}, conflictColumns []string) (sql.Result, error) {
+	return f.upsert(nil, table, obj)
+}
+
+// CopyFrom record query "COPY <table>" with every copied row as arg
+func (f *FakeDB) CopyFrom(ctx context.Context, table string, columns []string, src database.RowSource) (int64, error) {
+	var rows []interface{}
+	for src.Next() {
+		values, err := src.Values()
+		if err != nil {
+			return 0, err
+		}
+		rows = append(rows, values)
+	}
+	if err := src.Err(); err != nil {
+		return 0, err
+	}
+
+	stub := f.record(nil, "COPY "+table, rows)
+	if stub.err != nil {
+		return 0, stub.err
+	}
+	return int64(len(rows)), nil
+}
+
+func (f *FakeDB) Begin() (database.Tx, error) {
+	return f.begin(), nil
+}
+
+func (f *FakeDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (database.Tx, error) {
+	tx := f.begin()
+	tx.readOnly = opts != nil && opts.ReadOnly
+	return tx, nil
+}
+
+func (f *FakeDB) BeginReadOnly(ctx context.Context) (database.Tx, error) {
+	tx := f.begin()
+	tx.readOnly = true
+	return tx, nil
+}
+
+// WithTransaction commit when fn succeed and roll back when it return error or panic
+func (f *FakeDB) WithTransaction(ctx context.Context, fn func(tx database.Tx) error) error {
+	tx := f.begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExecuteTx run fn once like WithTransaction, serialization failure is not simulated
+func (f *FakeDB) ExecuteTx(ctx context.Context, fn func(tx database.Tx) error) error {
+	return f.WithTransaction(ctx, fn)
+}
+
+// Listen return channel receiving payload sent with Notify, it is closed when ctx is done
+func (f *FakeDB) Listen(ctx context.Context, channel string) (<-chan database.Notification, error) {
+	notifications := make(chan database.Notification, 16)
+	f.mu.Lock()
+	if f.listeners == nil {
+		f.listeners = map[string][]chan database.Notification{}
+	}
+	f.listeners[channel] = append(f.listeners[channel], notifications)
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		listeners := f.listeners[channel]
+		for i, v := range listeners {
+			if v == notifications {
+				f.listeners[channel] = append(listeners[:i], listeners[i+1:]...)
+				break
+			}
+		}
+		close(notifications)
+	}()
+	return notifications, nil
+}
+
+// Notify deliver payload to every listener of channel, it block when listener buffer is full
+func (f *FakeDB) Notify(channel, payload string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, listener := range f.listeners[channel] {
+		listener <- database.Notification{Channel: channel, Payload: payload}
+	}
+}
+
+// AcquireLock hold in-process lock of key, it block until lock is released or ctx is done
+func (f *FakeDB) AcquireLock(ctx context.Context, key string) (func(), error) {
+	f.mu.Lock()
+	if f.locks == nil {
+		f.locks = map[string]chan struct{}{}
+	}
+	lock, ok := f.locks[key]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		f.locks[key] = lock
+	}
+	f.mu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *FakeDB) StmtCacheStats() database.StmtCacheStats {
+	return database.StmtCacheStats{}
+}
+
+func (f *FakeDB) Prepare(ctx context.Context, query string) (database.Stmt, error) {
+	return &fakeStmt{db: f, query: query}, nil
+}
+
+func (f *FakeDB) PrepareNamed(ctx context.Context, query string) (database.NamedStmt, error) {
+	return &fakeNamedStmt{fakeStmt{db: f, query: query}}, nil
+}
+
+func (f *FakeDB) NamedPrepare(ctx context.Context, query string) (database.Stmt, error) {
+	return &fakeStmt{db: f, query: query}, nil
+}
+
+// Queries return queries executed inside the transaction
+func (tx *FakeTx) Queries() []Query {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return append([]Query{}, tx.queries...)
+}
+
+func (tx *FakeTx) Committed() bool {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return tx.committed
+}
+
+func (tx *FakeTx) RolledBack() bool {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return tx.rolledBack
+}
+
+// ReadOnly report whether the transaction is started by BeginReadOnly or BeginTx with read-only option
+func (tx *FakeTx) ReadOnly() bool {
+	return tx.readOnly
+}
+
+func (tx *FakeTx) finish(commit bool) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.committed || tx.rolledBack {
+		return sql.ErrTxDone
+	}
+	tx.committed = commit
+	tx.rolledBack = !commit
+	return nil
+}
+
+func (tx *FakeTx) Commit() error {
+	return tx.finish(true)
+}
+
+func (tx *FakeTx) Rollback() error {
+	return tx.finish(false)
+}
+
+func (tx *FakeTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.db.exec(tx, query, args)
+}
+
+func (tx *FakeTx) NamedExec(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return tx.db.exec(tx, query, []interface{}{arg})
+}
+
+func (tx *FakeTx) NamedQueryRowx(ctx context.Context, query string, arg interface{}) *sqlx.Row {
+	tx.db.record(tx, query, []interface{}{arg})
+	return nil
+}
+
+func (tx *FakeTx) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return tx.db.get(tx, dest, query, args)
+}
+
+func (tx *FakeTx) NamedGet(ctx context.Context, dest interface{}, query string, arg interface{}) error {
+	return tx.db.get(tx, dest, query, []interface{}{arg})
+}
+
+func (tx *FakeTx) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return tx.db.selectx(tx, dest, query, args)
+}
+
+func (tx *FakeTx) NamedSelect(ctx context.Context, dest interface{}, query string, arg interface{}) error {
+	return tx.db.selectx(tx, dest, query, []interface{}{arg})
+}
+
+func (tx *FakeTx) Queryx(ctx context.Context, query string, args ...interface{}) (database.Rows, error) {
+	return tx.db.queryx(tx, query, args)
+}
+
+func (tx *FakeTx) SelectMaps(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	err := tx.db.selectx(tx, &result, query, args)
+	return result, err
+}
+
+func (tx *FakeTx) Each(ctx context.Context, query string, args []interface{}, fn func(row database.Row) error) error {
+	return tx.db.each(tx, query, args, fn)
+}
+
+func (tx *FakeTx) ExecScript(ctx context.Context, script string, opts ...database.ScriptOption) error {
+	return tx.db.execScript(tx, script)
+}
+
+func (tx *FakeTx) BulkInsert(ctx context.Context, table string, rows []interface{}, opts ...database.BulkOption) (int64, error) {
+	return tx.db.bulkInsert(tx, table, rows)
+}
+
+func (tx *FakeTx) Upsert(ctx context.Context, table string, obj interface{}, conflictColumns []string) (sql.Result, error) {
+	return tx.db.upsert(tx, table, obj)
+}
+
+func (tx *FakeTx) Prepare(ctx context.Context, query string) (database.Stmt, error) {
+	return &fakeStmt{db: tx.db, tx: tx, query: query}, nil
+}
+
+func (tx *FakeTx) PrepareNamed(ctx context.Context, query string) (database.NamedStmt, error) {
+	return &fakeNamedStmt{fakeStmt{db: tx.db, tx: tx, query: query}}, nil
+}
+
+func (tx *FakeTx) NamedPrepare(ctx context.Context, query string) (database.Stmt, error) {
+	return &fakeStmt{db: tx.db, tx: tx, query: query}, nil
+}
+
+// Savepoint record query "SAVEPOINT <name>"
+func (tx *FakeTx) Savepoint(name string) error {
+	_, err := tx.db.exec(tx, "SAVEPOINT "+name, nil)
+	return err
+}
+
+func (tx *FakeTx) RollbackTo(name string) error {
+	_, err := tx.db.exec(tx, "ROLLBACK TO SAVEPOINT "+name, nil)
+	return err
+}
+
+func (tx *FakeTx) ReleaseSavepoint(name string) error {
+	_, err := tx.db.exec(tx, "RELEASE SAVEPOINT "+name, nil)
+	return err
+}
+
+func (stmt *fakeStmt) Exec(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	return stmt.db.exec(stmt.tx, stmt.query, args)
+}
+
+func (stmt *fakeStmt) Get(ctx context.Context, dest interface{}, args ...interface{}) error {
+	return stmt.db.get(stmt.tx, dest, stmt.query, args)
+}
+
+func (stmt *fakeStmt) Select(ctx context.Context, dest interface{}, args ...interface{}) error {
+	return stmt.db.selectx(stmt.tx, dest, stmt.query, args)
+}
+
+func (stmt *fakeStmt) Close() error {
+	return nil
+}
+
+func (stmt *fakeNamedStmt) Exec(ctx context.Context, arg interface{}) (sql.Result, error) {
+	return stmt.stmt.Exec(ctx, arg)
+}
+
+func (stmt *fakeNamedStmt) Get(ctx context.Context, dest interface{}, arg interface{}) error {
+	return stmt.stmt.Get(ctx, dest, arg)
+}
+
+func (stmt *fakeNamedStmt) Select(ctx context.Context, dest interface{}, arg interface{}) error {
+	return stmt.stmt.Select(ctx, dest, arg)
+}
+
+func (stmt *fakeNamedStmt) Close() error {
+	return stmt.stmt.Close()
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+func (r *fakeRows) Next() bool {
+	r.index++
+	return r.index < r.items.Len()
+}
+
+// Scan assign item to single dest, or each element of []interface{} item to dests
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	item := r.items.Index(r.index).Interface()
+	if values, ok := item.([]interface{}); ok {
+		if len(values) != len(dest) {
+			return fmt.Errorf("Expected %d destination arguments in Scan, not %d", len(values), len(dest))
+		}
+		for i, v := range values {
+			if err := assign(dest[i], v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(dest) != 1 {
+		return fmt.Errorf("Expected 1 destination argument in Scan, not %d", len(dest))
+	}
+	return assign(dest[0], item)
+}
+
+func (r *fakeRows) StructScan(dest interface{}) error {
+	return assign(dest, r.items.Index(r.index).Interface())
+}
+
+// MapScan copy map[string]interface{} item into dest
+func (r *fakeRows) MapScan(dest map[string]interface{}) error {
+	item, ok := r.items.Index(r.index).Interface().(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Stubbed item of type %s can not be scanned into map", r.items.Index(r.index).Type())
+	}
+	for k, v := range item {
+		dest[k] = v
+	}
+	return nil
+}
+
+// SliceScan return []interface{} item as is, other item is wrapped into single element slice
+func (r *fakeRows) SliceScan() ([]interface{}, error) {
+	item := r.items.Index(r.index).Interface()
+	if values, ok := item.([]interface{}); ok {
+		return values, nil
+	}
+	return []interface{}{item}, nil
+}
+
+func (r *fakeRows) Columns() ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeRows) Err() error {
+	return nil
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
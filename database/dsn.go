@@ -0,0 +1,99 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ConfigFromFields build DSN from separate fields, so special characters in password are escaped correctly
+type ConfigFromFields struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+
+	// database name, file path for sqlite3
+	Name string
+
+	// postgres sslmode: disable, require, verify-ca or verify-full
+	// it is translated to tls param for mysql and encrypt param for sqlserver
+	SSLMode string
+
+	// additional driver specific params, eg: connect_timeout, parseTime
+	Params map[string]string
+}
+
+// DSN return data source name for driver
+func (f ConfigFromFields) DSN(driver string) (string, error) {
+	params := url.Values{}
+	for k, v := range f.Params {
+		params.Set(k, v)
+	}
+
+	switch driver {
+	case "postgres", "clickhouse":
+		if f.SSLMode != "" && driver == "postgres" {
+			params.Set("sslmode", f.SSLMode)
+		} else if f.SSLMode != "" {
+			params.Set("secure", strconv.FormatBool(f.SSLMode != "disable"))
+			params.Set("skip_verify", strconv.FormatBool(f.SSLMode == "require"))
+		}
+		u := url.URL{Scheme: driver, Host: f.address(), Path: "/" + f.Name, RawQuery: params.Encode()}
+		if f.User != "" {
+			u.User = url.UserPassword(f.User, f.Password)
+		}
+		return u.String(), nil
+	case "mysql":
+		cfg := mysql.NewConfig()
+		cfg.User = f.User
+		cfg.Passwd = f.Password
+		cfg.Net = "tcp"
+		cfg.Addr = f.address()
+		cfg.DBName = f.Name
+		if len(f.Params) > 0 {
+			cfg.Params = f.Params
+		}
+		switch f.SSLMode {
+		case "disable":
+			cfg.TLSConfig = "false"
+		case "require", "verify-ca":
+			cfg.TLSConfig = "skip-verify"
+		case "verify-full":
+			cfg.TLSConfig = "true"
+		}
+		return cfg.FormatDSN(), nil
+	case "sqlserver", "mssql":
+		if f.Name != "" {
+			params.Set("database", f.Name)
+		}
+		switch f.SSLMode {
+		case "disable":
+			params.Set("encrypt", "disable")
+		case "require", "verify-ca":
+			params.Set("encrypt", "true")
+			params.Set("TrustServerCertificate", "true")
+		case "verify-full":
+			params.Set("encrypt", "true")
+		}
+		u := url.URL{Scheme: "sqlserver", Host: f.address(), RawQuery: params.Encode()}
+		if f.User != "" {
+			u.User = url.UserPassword(f.User, f.Password)
+		}
+		return u.String(), nil
+	case "sqlite3":
+		u := url.URL{Scheme: "file", Opaque: f.Name, RawQuery: params.Encode()}
+		return u.String(), nil
+	}
+	return "", fmt.Errorf("DSN builder does not support driver %s", driver)
+}
+
+func (f ConfigFromFields) address() string {
+	if f.Port == 0 {
+		return f.Host
+	}
+	return net.JoinHostPort(f.Host, strconv.Itoa(f.Port))
+}
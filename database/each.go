@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Each call fn for every row of query result without loading the whole result into memory,
+// iteration stop at first error returned by fn or when ctx is done
+func (db *Database) Each(ctx context.Context, query string, args []interface{}, fn func(row Row) error) error {
+	release, err := db.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	return hookedEach(ctx, db.connection, db.hooks, db.bind(query), args, fn)
+}
+
+func (tx *DBTransaction) Each(ctx context.Context, query string, args []interface{}, fn func(row Row) error) error {
+	return hookedEach(ctx, tx.transaction, tx.hooks, tx.bind(query), args, fn)
+}
+
+// hookedEach duration cover the whole iteration including fn
+func hookedEach(ctx context.Context, queryer sqlx.QueryerContext, hooks []Hook, query string, args []interface{}, fn func(row Row) error) error {
+	return runHooks(ctx, hooks, "Each", query, args, func(ctx context.Context) error {
+		rows, err := queryer.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// postgres (and cockroachdb) SQLSTATE codes
+const (
+	pqUniqueViolation      = "23505"
+	pqForeignKeyViolation  = "23503"
+	pqDeadlockDetected     = "40P01"
+	pqSerializationFailure = "40001"
+)
+
+// mysql error numbers
+const (
+	mysqlDuplicateEntry  = 1062
+	mysqlRowIsReferenced = 1451
+	mysqlNoReferencedRow = 1452
+	mysqlDeadlock        = 1213
+)
+
+// IsUniqueViolation check err is caused by unique or primary key constraint
+func IsUniqueViolation(err error) bool {
+	return hasPQCode(err, pqUniqueViolation) || hasMySQLNumber(err, mysqlDuplicateEntry) ||
+		isSQLiteUniqueViolation(err)
+}
+
+// IsForeignKeyViolation check err is caused by foreign key constraint, either insert of missing
+// parent or delete of referenced row
+func IsForeignKeyViolation(err error) bool {
+	return hasPQCode(err, pqForeignKeyViolation) || hasMySQLNumber(err, mysqlRowIsReferenced, mysqlNoReferencedRow) ||
+		isSQLiteForeignKeyViolation(err)
+}
+
+// IsDeadlock check err is caused by deadlock, the transaction can be retried
+func IsDeadlock(err error) bool {
+	return hasPQCode(err, pqDeadlockDetected) || hasMySQLNumber(err, mysqlDeadlock)
+}
+
+// IsSerializationFailure check err is caused by concurrent update under serializable isolation,
+// the transaction can be retried. mysql report it as deadlock which has SQLSTATE 40001
+func IsSerializationFailure(err error) bool {
+	return hasPQCode(err, pqSerializationFailure) || hasMySQLNumber(err, mysqlDeadlock)
+}
+
+func hasPQCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+func hasMySQLNumber(err error, numbers ...uint16) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	for _, number := range numbers {
+		if mysqlErr.Number == number {
+			return true
+		}
+	}
+	return false
+}
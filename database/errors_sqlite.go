@@ -0,0 +1,30 @@
+//go:build cgo
+
+package database
+
+import (
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func isSQLiteUniqueViolation(err error) bool {
+	return hasSQLiteCode(err, sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey)
+}
+
+func isSQLiteForeignKeyViolation(err error) bool {
+	return hasSQLiteCode(err, sqlite3.ErrConstraintForeignKey)
+}
+
+func hasSQLiteCode(err error, codes ...sqlite3.ErrNoExtended) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	for _, code := range codes {
+		if sqliteErr.ExtendedCode == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+//go:build !cgo
+
+package database
+
+// go-sqlite3 error types only exist in cgo build, sqlite driver itself does not work without cgo
+
+func isSQLiteUniqueViolation(err error) bool {
+	return false
+}
+
+func isSQLiteForeignKeyViolation(err error) bool {
+	return false
+}
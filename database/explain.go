@@ -0,0 +1,259 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HighCostThreshold planner cost above which plan node is flagged as high cost
+var HighCostThreshold = 10000.0
+
+type Plan struct {
+	Driver string
+
+	// plan as returned by database, eg: json for postgres and mysql
+	Raw string
+
+	// plan nodes flattened in depth first order
+	Nodes []PlanNode
+
+	// estimated cost of the whole query, not reported by sqlite
+	TotalCost float64
+}
+
+type PlanNode struct {
+	// eg: Seq Scan, Index Scan for postgres, ALL, ref for mysql, SCAN, SEARCH for sqlite
+	Operation string
+	Table     string
+	Depth     int
+
+	// estimated cost and rows, not reported by sqlite
+	Cost float64
+	Rows float64
+
+	// actual execution time in milliseconds, only with analyze on postgres
+	ActualTime float64
+
+	// node read the whole table
+	SeqScan bool
+
+	// node cost exceed HighCostThreshold
+	HighCost bool
+}
+
+type explainAnalyzeKey struct{}
+
+// WithExplainAnalyze make Explain execute the query with ANALYZE on postgres and mysql to report actual time,
+// the query is really executed so do not use it with writes outside rolled back transaction
+func WithExplainAnalyze(ctx context.Context) context.Context {
+	return context.WithValue(ctx, explainAnalyzeKey{}, true)
+}
+
+func isExplainAnalyze(ctx context.Context) bool {
+	analyze, _ := ctx.Value(explainAnalyzeKey{}).(bool)
+	return analyze
+}
+
+// SeqScans return nodes reading the whole table
+func (p Plan) SeqScans() []PlanNode {
+	var nodes []PlanNode
+	for _, node := range p.Nodes {
+		if node.SeqScan {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// HighCostNodes return nodes whose cost exceed HighCostThreshold
+func (p Plan) HighCostNodes() []PlanNode {
+	var nodes []PlanNode
+	for _, node := range p.Nodes {
+		if node.HighCost {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Explain return execution plan of query for postgres, mysql and sqlite3, eg: from debug endpoint in staging
+func (db *Database) Explain(ctx context.Context, query string, args ...interface{}) (Plan, error) {
+	plan := Plan{Driver: db.connection.DriverName()}
+	release, err := db.acquire()
+	if err != nil {
+		return plan, err
+	}
+	defer release()
+
+	query = db.bind(query)
+	analyze := isExplainAnalyze(ctx)
+	switch plan.Driver {
+	case "postgres":
+		format := "FORMAT JSON"
+		if analyze {
+			format = "ANALYZE, " + format
+		}
+		if err = hookedGet(ctx, db.connection, db.hooks, "Explain", &plan.Raw, "EXPLAIN ("+format+") "+query, args); err == nil {
+			err = parsePostgresPlan(&plan)
+		}
+	case "mysql":
+		// mysql EXPLAIN ANALYZE only output text tree, so it is returned as Raw without nodes
+		if analyze {
+			return plan, hookedGet(ctx, db.connection, db.hooks, "Explain", &plan.Raw, "EXPLAIN ANALYZE "+query, args)
+		}
+		if err = hookedGet(ctx, db.connection, db.hooks, "Explain", &plan.Raw, "EXPLAIN FORMAT=JSON "+query, args); err == nil {
+			err = parseMySQLPlan(&plan)
+		}
+	case "sqlite3":
+		err = explainSQLite(ctx, db, &plan, query, args)
+	default:
+		return plan, fmt.Errorf("Explain is not supported by %s driver", plan.Driver)
+	}
+
+	for i := range plan.Nodes {
+		plan.Nodes[i].HighCost = plan.Nodes[i].Cost > HighCostThreshold
+	}
+	return plan, err
+}
+
+type postgresPlanNode struct {
+	NodeType   string             `json:"Node Type"`
+	Relation   string             `json:"Relation Name"`
+	TotalCost  float64            `json:"Total Cost"`
+	PlanRows   float64            `json:"Plan Rows"`
+	ActualTime float64            `json:"Actual Total Time"`
+	Plans      []postgresPlanNode `json:"Plans"`
+}
+
+func parsePostgresPlan(plan *Plan) error {
+	var explain []struct {
+		Plan postgresPlanNode `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan.Raw), &explain); err != nil {
+		return err
+	}
+	if len(explain) == 0 {
+		return nil
+	}
+
+	var walk func(node postgresPlanNode, depth int)
+	walk = func(node postgresPlanNode, depth int) {
+		plan.Nodes = append(plan.Nodes, PlanNode{
+			Operation:  node.NodeType,
+			Table:      node.Relation,
+			Depth:      depth,
+			Cost:       node.TotalCost,
+			Rows:       node.PlanRows,
+			ActualTime: node.ActualTime,
+			SeqScan:    node.NodeType == "Seq Scan",
+		})
+		for _, child := range node.Plans {
+			walk(child, depth+1)
+		}
+	}
+	walk(explain[0].Plan, 0)
+	plan.TotalCost = explain[0].Plan.TotalCost
+	return nil
+}
+
+// parseMySQLPlan walk query_block of EXPLAIN FORMAT=JSON, every object with table_name is a node
+func parseMySQLPlan(plan *Plan) error {
+	var explain map[string]interface{}
+	if err := json.Unmarshal([]byte(plan.Raw), &explain); err != nil {
+		return err
+	}
+	block, _ := explain["query_block"].(map[string]interface{})
+	if costInfo, ok := block["cost_info"].(map[string]interface{}); ok {
+		plan.TotalCost = jsonNumber(costInfo["query_cost"])
+	}
+
+	var walk func(value interface{}, depth int)
+	walk = func(value interface{}, depth int) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if table, ok := v["table_name"].(string); ok {
+				node := PlanNode{Table: table, Depth: depth, Rows: jsonNumber(v["rows_examined_per_scan"])}
+				node.Operation, _ = v["access_type"].(string)
+				node.SeqScan = node.Operation == "ALL"
+				if costInfo, ok := v["cost_info"].(map[string]interface{}); ok {
+					node.Cost = jsonNumber(costInfo["prefix_cost"])
+				}
+				plan.Nodes = append(plan.Nodes, node)
+				depth++
+			}
+			// sorted so node order does not depend on map iteration
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				if key != "cost_info" {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(v[key], depth)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child, depth)
+			}
+		}
+	}
+	walk(block, 0)
+	return nil
+}
+
+// jsonNumber mysql report cost as string and rows as number
+func jsonNumber(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+func explainSQLite(ctx context.Context, db *Database, plan *Plan, query string, args []interface{}) error {
+	var rows []struct {
+		ID      int    `db:"id"`
+		Parent  int    `db:"parent"`
+		NotUsed int    `db:"notused"`
+		Detail  string `db:"detail"`
+	}
+	if err := hookedSelect(ctx, db.connection, db.hooks, "Explain", &rows, "EXPLAIN QUERY PLAN "+query, args); err != nil {
+		return err
+	}
+
+	depths := map[int]int{}
+	var raw []string
+	for _, row := range rows {
+		depth := 0
+		if parentDepth, ok := depths[row.Parent]; ok {
+			depth = parentDepth + 1
+		}
+		depths[row.ID] = depth
+		raw = append(raw, strings.Repeat("  ", depth)+row.Detail)
+
+		// eg: SCAN users, SEARCH users USING INDEX idx_users_email (email=?)
+		words := strings.Fields(row.Detail)
+		node := PlanNode{Depth: depth}
+		if len(words) > 0 {
+			node.Operation = words[0]
+		}
+		if len(words) > 1 && (node.Operation == "SCAN" || node.Operation == "SEARCH") {
+			node.Table = words[1]
+			if node.Table == "TABLE" && len(words) > 2 {
+				node.Table = words[2]
+			}
+			node.SeqScan = node.Operation == "SCAN" && !strings.Contains(row.Detail, "INDEX")
+		}
+		plan.Nodes = append(plan.Nodes, node)
+	}
+	plan.Raw = strings.Join(raw, "\n")
+	return nil
+}
@@ -0,0 +1,221 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/database"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	// fixture files, eg: embed.FS or os.DirFS("testdata/fixtures")
+	// file name is the table name with .yml, .yaml or .json extension, content is a list of rows,
+	// eg: users.yml
+	//   - id: 1
+	//     email: alice@example.com
+	//     created_at: {{ ago "24h" }}
+	FS fs.FS
+
+	// directory of fixture files inside FS
+	// by default root of FS
+	Dir string
+
+	// tables loaded first in this order, eg: parent tables of foreign keys
+	// by default tables are loaded in file name order
+	Order []string
+
+	// keep existing rows of fixture tables
+	// by default every fixture table is emptied before loading
+	SkipTruncate bool
+}
+
+type Loader interface {
+	// Load empty fixture tables in reverse order and insert fixture rows inside one transaction
+	Load(ctx context.Context) error
+}
+
+type fixture struct {
+	table   string
+	file    string
+	content string
+}
+
+type loader struct {
+	db           database.DB
+	fixtures     []fixture
+	skipTruncate bool
+}
+
+const ErrorFailedLoadFixture = "Failed to load fixture %s. Error: %s"
+
+var (
+	ErrInvalidTable = errors.New("Fixture file name must be a table name with .yml, .yaml or .json extension")
+
+	filePattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\.(yml|yaml|json)$`)
+)
+
+// New read fixture files from config FS, content is rendered as text/template on every Load with functions
+// now, ago "48h" and fromNow "1h" returning RFC 3339 timestamp
+func New(db database.DB, cfg Config) (Loader, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = "."
+	}
+
+	entries, err := fs.ReadDir(cfg.FS, cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byTable := map[string]fixture{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := filePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf(ErrorFailedLoadFixture, entry.Name(), ErrInvalidTable)
+		}
+		if existing, ok := byTable[match[1]]; ok {
+			return nil, fmt.Errorf("Duplicate fixture of table %s: %s and %s", match[1], existing.file, entry.Name())
+		}
+
+		content, err := fs.ReadFile(cfg.FS, path.Join(cfg.Dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		byTable[match[1]] = fixture{table: match[1], file: entry.Name(), content: string(content)}
+	}
+
+	l := &loader{db: db, skipTruncate: cfg.SkipTruncate}
+	for _, table := range cfg.Order {
+		if f, ok := byTable[table]; ok {
+			l.fixtures = append(l.fixtures, f)
+			delete(byTable, table)
+		}
+	}
+	var rest []string
+	for table := range byTable {
+		rest = append(rest, table)
+	}
+	sort.Strings(rest)
+	for _, table := range rest {
+		l.fixtures = append(l.fixtures, byTable[table])
+	}
+	return l, nil
+}
+
+func (l *loader) Load(ctx context.Context) error {
+	funcs := templateFuncs(time.Now())
+	rows := make([][]map[string]interface{}, len(l.fixtures))
+	for i, f := range l.fixtures {
+		var err error
+		if rows[i], err = f.parse(funcs); err != nil {
+			return fmt.Errorf(ErrorFailedLoadFixture, f.file, err)
+		}
+	}
+
+	return l.db.WithTransaction(ctx, func(tx database.Tx) error {
+		// children are emptied before parents referenced by foreign key
+		for i := len(l.fixtures) - 1; i >= 0 && !l.skipTruncate; i-- {
+			if _, err := tx.Exec(ctx, "DELETE FROM "+l.fixtures[i].table); err != nil {
+				return fmt.Errorf(ErrorFailedLoadFixture, l.fixtures[i].file, err)
+			}
+		}
+
+		for i, f := range l.fixtures {
+			for _, row := range rows[i] {
+				query, args := insertQuery(f.table, row)
+				if _, err := tx.Exec(ctx, l.db.Rebind(query), args...); err != nil {
+					return fmt.Errorf(ErrorFailedLoadFixture, f.file, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func templateFuncs(now time.Time) template.FuncMap {
+	format := func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return template.FuncMap{
+		"now": func() string {
+			return format(now)
+		},
+		"ago": func(d string) (string, error) {
+			duration, err := time.ParseDuration(d)
+			return format(now.Add(-duration)), err
+		},
+		"fromNow": func(d string) (string, error) {
+			duration, err := time.ParseDuration(d)
+			return format(now.Add(duration)), err
+		},
+	}
+}
+
+// parse render template and decode rows, RFC 3339 string is converted to time.Time,
+// nested object and list are encoded as json, eg: for jsonb column
+func (f fixture) parse(funcs template.FuncMap) ([]map[string]interface{}, error) {
+	tmpl, err := template.New(f.file).Funcs(funcs).Parse(f.content)
+	if err != nil {
+		return nil, err
+	}
+	var content bytes.Buffer
+	if err = tmpl.Execute(&content, nil); err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	if strings.HasSuffix(f.file, ".json") {
+		err = json.Unmarshal(content.Bytes(), &rows)
+	} else {
+		err = yaml.Unmarshal(content.Bytes(), &rows)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		for column, value := range row {
+			switch v := value.(type) {
+			case string:
+				if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+					row[column] = t
+				}
+			case map[string]interface{}, []interface{}:
+				encoded, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+				row[column] = string(encoded)
+			}
+		}
+	}
+	return rows, nil
+}
+
+func insertQuery(table string, row map[string]interface{}) (string, []interface{}) {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		args[i] = row[column]
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")), args
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+type HealthReport struct {
+	Driver        string        `json:"driver"`
+	ServerVersion string        `json:"server_version,omitempty"`
+	Latency       time.Duration `json:"latency"`
+
+	OpenConnections int           `json:"open_connections"`
+	IdleConnections int           `json:"idle_connections"`
+	InUse           int           `json:"in_use"`
+	MaxOpen         int           `json:"max_open"`
+	WaitCount       int64         `json:"wait_count"`
+	WaitDuration    time.Duration `json:"wait_duration"`
+}
+
+// serverVersionQuery query returning server version per driver
+var serverVersionQuery = map[string]string{
+	"postgres":   "SHOW server_version",
+	"mysql":      "SELECT VERSION()",
+	"sqlite3":    "SELECT sqlite_version()",
+	"clickhouse": "SELECT version()",
+	"sqlserver":  "SELECT @@VERSION",
+	"mssql":      "SELECT @@VERSION",
+}
+
+// HealthCheck ping database and report round trip latency, pool stats and server version,
+// pool stats are filled even when ping fail
+func (db *Database) HealthCheck(ctx context.Context) (HealthReport, error) {
+	stats := db.connection.Stats()
+	report := HealthReport{
+		Driver:          db.connection.DriverName(),
+		OpenConnections: stats.OpenConnections,
+		IdleConnections: stats.Idle,
+		InUse:           stats.InUse,
+		MaxOpen:         stats.MaxOpenConnections,
+		WaitCount:       stats.WaitCount,
+		WaitDuration:    stats.WaitDuration,
+	}
+
+	start := time.Now()
+	if err := db.connection.PingContext(ctx); err != nil {
+		return report, err
+	}
+	report.Latency = time.Since(start)
+
+	if query, ok := serverVersionQuery[report.Driver]; ok {
+		if err := db.connection.GetContext(ctx, &report.ServerVersion, query); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Hook intercept every query, eg: logging, tracing and metrics
+// args are positional, named query is already bound before hooks are called
+type Hook interface {
+	// BeforeQuery is called before query is sent, returned context is passed to the query and AfterQuery
+	BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context
+	// AfterQuery is called when query finished with its error and duration
+	AfterQuery(ctx context.Context, query string, args []interface{}, err error, duration time.Duration)
+}
+
+// resultHook is implemented by hook which need result of exec, eg: rows affected for tracing,
+// it is called before AfterQuery
+type resultHook interface {
+	afterExec(ctx context.Context, result sql.Result)
+}
+
+type operationKey struct{}
+
+// Operation return name of the call being hooked, eg: Exec, Get, NamedSelect
+func Operation(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+// runHooks call fn wrapped by hooks, BeforeQuery run in registration order and AfterQuery in reverse order
+func runHooks(ctx context.Context, hooks []Hook, operation, query string, args []interface{}, fn func(ctx context.Context) error) error {
+	if len(hooks) == 0 {
+		return fn(ctx)
+	}
+
+	ctx = context.WithValue(ctx, operationKey{}, operation)
+	for _, hook := range hooks {
+		ctx = hook.BeforeQuery(ctx, query, args)
+	}
+
+	start := time.Now()
+	err := fn(ctx)
+	duration := time.Since(start)
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].AfterQuery(ctx, query, args, err, duration)
+	}
+	return err
+}
+
+func hookedExec(ctx context.Context, execer sqlx.ExecerContext, hooks []Hook, operation, query string, args []interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := runHooks(ctx, hooks, operation, query, args, func(ctx context.Context) error {
+		var err error
+		result, err = execer.ExecContext(ctx, query, args...)
+		if err == nil {
+			notifyResult(ctx, hooks, result)
+		}
+		return err
+	})
+	return result, err
+}
+
+func notifyResult(ctx context.Context, hooks []Hook, result sql.Result) {
+	for _, hook := range hooks {
+		if h, ok := hook.(resultHook); ok {
+			h.afterExec(ctx, result)
+		}
+	}
+}
+
+func hookedGet(ctx context.Context, queryer sqlx.QueryerContext, hooks []Hook, operation string, dest interface{}, query string, args []interface{}) error {
+	return runHooks(ctx, hooks, operation, query, args, func(ctx context.Context) error {
+		return sqlx.GetContext(ctx, queryer, dest, query, args...)
+	})
+}
+
+func hookedSelect(ctx context.Context, queryer sqlx.QueryerContext, hooks []Hook, operation string, dest interface{}, query string, args []interface{}) error {
+	return runHooks(ctx, hooks, operation, query, args, func(ctx context.Context) error {
+		return sqlx.SelectContext(ctx, queryer, dest, query, args...)
+	})
+}
+
+// hookedQueryx duration only cover query execution, not iteration of the rows
+func hookedQueryx(ctx context.Context, queryer sqlx.QueryerContext, hooks []Hook, operation, query string, args []interface{}) (Rows, error) {
+	var rows *sqlx.Rows
+	err := runHooks(ctx, hooks, operation, query, args, func(ctx context.Context) error {
+		var err error
+		rows, err = queryer.QueryxContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func hookedQueryRowx(ctx context.Context, queryer sqlx.QueryerContext, hooks []Hook, operation, query string, args []interface{}) *sqlx.Row {
+	var row *sqlx.Row
+	runHooks(ctx, hooks, operation, query, args, func(ctx context.Context) error {
+		row = queryer.QueryRowxContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+type Notification struct {
+	Channel string
+	Payload string
+}
+
+var ErrListenNotSupported = errors.New("Listen is only supported by postgres database opened with Connect")
+
+const (
+	listenMinReconnect = 100 * time.Millisecond
+	listenMaxReconnect = 10 * time.Second
+	listenPingInterval = 90 * time.Second
+)
+
+// Listen subscribe to postgres NOTIFY on channel using dedicated connection outside the pool,
+// connection is re-established automatically and notification sent while disconnected is lost.
+// returned channel is closed when ctx is done. credential of CredentialFunc is taken once and reused on reconnect
+func (db *Database) Listen(ctx context.Context, channel string) (<-chan Notification, error) {
+	if db.connection.DriverName() != "postgres" || db.dsn == "" {
+		return nil, ErrListenNotSupported
+	}
+
+	dsn := db.dsn
+	if db.credentials != nil {
+		user, password, err := db.credentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if dsn, err = dsnWithCredentials("postgres", dsn, user, password); err != nil {
+			return nil, err
+		}
+	}
+
+	onEvent := func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.WithFields(log.Fields{"channel": channel, "event": event}).Errorf("Database listener connection failed. Error: %s", err)
+		}
+	}
+
+	var listener *pq.Listener
+	if db.dial != nil {
+		listener = pq.NewDialListener(&pqDialer{dial: db.dial}, dsn, listenMinReconnect, listenMaxReconnect, onEvent)
+	} else {
+		listener = pq.NewListener(dsn, listenMinReconnect, listenMaxReconnect, onEvent)
+	}
+
+	// Listen block until server acknowledge, closing the listener unblock it
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- listener.Listen(channel)
+	}()
+	select {
+	case err := <-listenErr:
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+	case <-ctx.Done():
+		listener.Close()
+		return nil, ctx.Err()
+	}
+
+	notifications := make(chan Notification)
+	go func() {
+		defer close(notifications)
+		defer listener.Close()
+
+		ticker := time.NewTicker(listenPingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// detect half-open connection which never deliver notification
+				go listener.Ping()
+			case n := <-listener.Notify:
+				// nil is sent after reconnect
+				if n == nil {
+					continue
+				}
+				select {
+				case notifications <- Notification{Channel: n.Channel, Payload: n.Extra}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return notifications, nil
+}
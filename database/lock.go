@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"hash/fnv"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+var (
+	ErrLockNotSupported = errors.New("AcquireLock is only supported by postgres and mysql driver")
+	ErrLockNotAcquired  = errors.New("Failed to acquire database lock")
+)
+
+// mysqlMaxLockName mysql reject lock name longer than 64 characters
+const mysqlMaxLockName = 64
+
+// AcquireLock block until session level lock of key is held, using postgres advisory lock or mysql GET_LOCK.
+// lock is held by a dedicated connection until unlock is called or the connection is lost,
+// so it suits cron-style singleton across instances. ctx only bound the waiting
+func (db *Database) AcquireLock(ctx context.Context, key string) (func(), error) {
+	var lockQuery, unlockQuery string
+	var lockKey interface{}
+
+	switch db.connection.DriverName() {
+	case "postgres":
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		lockKey = int64(h.Sum64())
+		lockQuery, unlockQuery = "SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)"
+	case "mysql":
+		if len(key) > mysqlMaxLockName {
+			sum := sha1.Sum([]byte(key))
+			key = hex.EncodeToString(sum[:])
+		}
+		lockKey = key
+		// negative timeout wait forever, ctx cancellation abort the wait
+		lockQuery, unlockQuery = "SELECT COALESCE(GET_LOCK(?, -1), 0)", "SELECT RELEASE_LOCK(?)"
+	default:
+		return nil, ErrLockNotSupported
+	}
+
+	release, err := db.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	conn, err := db.connection.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if db.connection.DriverName() == "mysql" {
+		var acquired int
+		err = conn.QueryRowContext(ctx, lockQuery, lockKey).Scan(&acquired)
+		if err == nil && acquired != 1 {
+			err = ErrLockNotAcquired
+		}
+	} else {
+		_, err = conn.ExecContext(ctx, lockQuery, lockKey)
+	}
+	if err != nil {
+		// lock may be granted right after cancellation, discard the connection instead of returning it to pool
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		defer conn.Close()
+		var released sql.NullBool
+		if err := conn.QueryRowContext(context.Background(), unlockQuery, lockKey).Scan(&released); err != nil {
+			log.WithField("lock", key).Errorf("Failed to release database lock. Error: %s", err)
+		}
+	}, nil
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Manager hold named database handles, connection is opened lazily on first Get
+type Manager struct {
+	mu        sync.Mutex
+	configs   map[string]Config
+	databases map[string]*Database
+
+	// connecting hold in-progress connect per name, so it run outside mu and only once for concurrent Get
+	connecting map[string]*managerConnect
+}
+
+type managerConnect struct {
+	done chan struct{}
+	db   *Database
+	err  error
+}
+
+// managerPingTimeout bound ping of Get and Ping, so unreachable database fail fast
+const managerPingTimeout = 5 * time.Second
+
+type ManagerError struct {
+	// errors per database name
+	Errors map[string]error
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		configs:    map[string]Config{},
+		databases:  map[string]*Database{},
+		connecting: map[string]*managerConnect{},
+	}
+}
+
+func (e *ManagerError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := make([]string, 0, len(names))
+	for _, name := range names {
+		messages = append(messages, fmt.Sprintf("%s: %s", name, e.Errors[name]))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Register add database config under name, connection is not opened until Get
+func (m *Manager) Register(name string, cfg Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.configs[name]; ok {
+		return fmt.Errorf("Database %s is already registered", name)
+	}
+	m.configs[name] = cfg
+	return nil
+}
+
+// Get return database handle, connect on first call. connect of one database does not block Get of others
+func (m *Manager) Get(name string) (DB, error) {
+	return m.get(name)
+}
+
+func (m *Manager) get(name string) (*Database, error) {
+	m.mu.Lock()
+	if db, ok := m.databases[name]; ok {
+		m.mu.Unlock()
+		return db, nil
+	}
+
+	cfg, ok := m.configs[name]
+	if !ok {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("Database %s is not registered", name)
+	}
+
+	if c, ok := m.connecting[name]; ok {
+		m.mu.Unlock()
+		<-c.done
+		return c.db, c.err
+	}
+	c := &managerConnect{done: make(chan struct{})}
+	m.connecting[name] = c
+	m.mu.Unlock()
+
+	c.db, c.err = connectManaged(cfg)
+
+	m.mu.Lock()
+	delete(m.connecting, name)
+	if c.err == nil {
+		m.databases[name] = c.db
+	}
+	m.mu.Unlock()
+	close(c.done)
+
+	return c.db, c.err
+}
+
+func connectManaged(cfg Config) (*Database, error) {
+	db, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err = pingManaged(db); err != nil {
+		db.Close(context.Background())
+		return nil, err
+	}
+	return db, nil
+}
+
+func pingManaged(db *Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), managerPingTimeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
+
+// Ping connect and ping every registered database concurrently, error is *ManagerError
+func (m *Manager) Ping() error {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.configs))
+	for name := range m.configs {
+		names = append(names, name)
+	}
+	m.mu.Unlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := map[string]error{}
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			db, err := m.get(name)
+			if err == nil {
+				err = pingManaged(db)
+			}
+			if err != nil {
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &ManagerError{Errors: errs}
+	}
+	return nil
+}
+
+// Close close every opened connection pool, in-flight queries are finished first until ctx is done,
+// see Database.Close. handles returned by Get must not be used afterwards, error is *ManagerError
+func (m *Manager) Close(ctx context.Context) error {
+	m.mu.Lock()
+	databases := m.databases
+	m.databases = map[string]*Database{}
+	m.mu.Unlock()
+
+	errs := map[string]error{}
+	for name, db := range databases {
+		if err := db.Close(ctx); err != nil {
+			errs[name] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ManagerError{Errors: errs}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SelectMaps scan every row into map of column name to value for ad-hoc and reporting query,
+// []byte value is converted to string so the result can be marshalled to json as is
+func (db *Database) SelectMaps(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	query = db.bind(query)
+	var result []map[string]interface{}
+	err := db.withRetry(ctx, IsTransient, func() error {
+		var err error
+		result, err = hookedSelectMaps(ctx, db.connection, db.hooks, query, args)
+		return err
+	})
+	return result, err
+}
+
+func (tx *DBTransaction) SelectMaps(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	return hookedSelectMaps(ctx, tx.transaction, tx.hooks, tx.bind(query), args)
+}
+
+func hookedSelectMaps(ctx context.Context, queryer sqlx.QueryerContext, hooks []Hook, query string, args []interface{}) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	err := runHooks(ctx, hooks, "SelectMaps", query, args, func(ctx context.Context) error {
+		rows, err := queryer.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			row := map[string]interface{}{}
+			if err := rows.MapScan(row); err != nil {
+				return err
+			}
+			for column, value := range row {
+				if b, ok := value.([]byte); ok {
+					row[column] = string(b)
+				}
+			}
+			result = append(result, row)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector export connection pool stats and query latency of every database opened with EnableMetrics
+type poolCollector struct {
+	mu        sync.RWMutex
+	databases map[string]*sqlx.DB
+
+	latency      *prometheus.HistogramVec
+	openConns    *prometheus.Desc
+	idleConns    *prometheus.Desc
+	inUseConns   *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+type metricsHook struct {
+	name string
+}
+
+var defaultCollector = newPoolCollector()
+
+// Collector return collector of database metrics, register it once, eg: prometheus.MustRegister(database.Collector())
+func Collector() prometheus.Collector {
+	return defaultCollector
+}
+
+func newPoolCollector() *poolCollector {
+	labels := []string{"db"}
+	return &poolCollector{
+		databases: map[string]*sqlx.DB{},
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Latency of database queries",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"db", "operation", "status"}),
+		openConns:    prometheus.NewDesc("db_pool_open_connections", "Number of established connections both in use and idle", labels, nil),
+		idleConns:    prometheus.NewDesc("db_pool_idle_connections", "Number of idle connections", labels, nil),
+		inUseConns:   prometheus.NewDesc("db_pool_in_use_connections", "Number of connections currently in use", labels, nil),
+		waitCount:    prometheus.NewDesc("db_pool_wait_count_total", "Total number of connections waited for", labels, nil),
+		waitDuration: prometheus.NewDesc("db_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection", labels, nil),
+	}
+}
+
+// add start exporting pool stats of connection under name, database with the same name is replaced
+func (c *poolCollector) add(name string, connection *sqlx.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.databases[name] = connection
+}
+
+// remove stop exporting pool stats of closed connection, name reused by another pool is kept
+func (c *poolCollector) remove(name string, connection *sqlx.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.databases[name] == connection {
+		delete(c.databases, name)
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.latency.Describe(ch)
+	ch <- c.openConns
+	ch <- c.idleConns
+	ch <- c.inUseConns
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.latency.Collect(ch)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for name, connection := range c.databases {
+		stats := connection.Stats()
+		ch <- prometheus.MustNewConstMetric(c.openConns, prometheus.GaugeValue, float64(stats.OpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.Idle), name)
+		ch <- prometheus.MustNewConstMetric(c.inUseConns, prometheus.GaugeValue, float64(stats.InUse), name)
+		ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount), name)
+		ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds(), name)
+	}
+}
+
+func (h *metricsHook) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	return ctx
+}
+
+func (h *metricsHook) AfterQuery(ctx context.Context, query string, args []interface{}, err error, duration time.Duration) {
+	status := "ok"
+	if err != nil && err != ErrNoRows {
+		status = "error"
+	}
+	defaultCollector.latency.WithLabelValues(h.name, Operation(ctx), status).Observe(duration.Seconds())
+}
@@ -0,0 +1,255 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/database"
+)
+
+type Config struct {
+	// migration files, usually embed.FS
+	// file name must be <version>_<name>.up.sql or <version>_<name>.down.sql, eg: 20210101120000_create_users.up.sql
+	FS fs.FS
+
+	// directory of migration files inside FS
+	// by default root of FS
+	Dir string
+
+	// table tracking applied versions
+	// by default schema_migrations
+	Table string
+}
+
+type Migrator interface {
+	Up(ctx context.Context) error
+	Down(ctx context.Context, n int) error
+	Status(ctx context.Context) ([]Migration, error)
+}
+
+type Migration struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+
+	up   string
+	down string
+}
+
+type migrator struct {
+	db         database.DB
+	driver     string
+	table      string
+	migrations []*Migration
+}
+
+type appliedVersion struct {
+	Version   int64     `db:"version"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+const ErrorFailedMigrate = "Failed to migrate version %d (%s). Error: %s"
+
+var (
+	ErrInvalidTable = errors.New("Migration table name must only contain letters, digits and underscore")
+	ErrInvalidSteps = errors.New("Number of migrations to roll back must be positive")
+
+	filePattern  = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	tablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// New load migration files from config FS, each migration is run inside transaction,
+// mysql DSN must enable multiStatements when a file contains more than one statement
+func New(db database.DB, cfg Config) (Migrator, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = "."
+	}
+	if cfg.Table == "" {
+		cfg.Table = "schema_migrations"
+	}
+	if !tablePattern.MatchString(cfg.Table) {
+		return nil, ErrInvalidTable
+	}
+
+	migrations, err := load(cfg.FS, cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var driver string
+	if d, ok := db.(interface{ DriverName() string }); ok {
+		driver = d.DriverName()
+	}
+	return &migrator{db: db, driver: driver, table: cfg.Table, migrations: migrations}, nil
+}
+
+func load(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := filePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("Duplicate migration version %d: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("Migration version %d (%s) has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func (m *migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.Exec(ctx, createTableQuery(m.driver, m.table))
+	return err
+}
+
+// createTableQuery return DDL of migration table, sqlserver has no IF NOT EXISTS and its TIMESTAMP is rowversion
+func createTableQuery(driver, table string) string {
+	if driver == "sqlserver" || driver == "mssql" {
+		return "IF OBJECT_ID(N'" + table + "', N'U') IS NULL CREATE TABLE " + table +
+			" (version BIGINT PRIMARY KEY, name NVARCHAR(255) NOT NULL, applied_at DATETIME2 NOT NULL)"
+	}
+	return "CREATE TABLE IF NOT EXISTS " + table +
+		" (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)"
+}
+
+func (m *migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var versions []appliedVersion
+	if err := m.db.Select(ctx, &versions, "SELECT version, applied_at FROM "+m.table); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]time.Time, len(versions))
+	for _, v := range versions {
+		applied[v.Version] = v.AppliedAt
+	}
+	return applied, nil
+}
+
+// Up apply every pending migration in version order, it stops at the first failing migration
+func (m *migrator) Up(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	insert := m.db.Rebind("INSERT INTO " + m.table + " (version, name, applied_at) VALUES (?, ?, ?)")
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err = m.db.WithTransaction(ctx, func(tx database.Tx) error {
+			if _, err := tx.Exec(ctx, migration.up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, insert, migration.Version, migration.Name, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf(ErrorFailedMigrate, migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// Down roll back the last n applied migrations in reverse version order
+func (m *migrator) Down(ctx context.Context, n int) error {
+	if n <= 0 {
+		return ErrInvalidSteps
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	remove := m.db.Rebind("DELETE FROM " + m.table + " WHERE version = ?")
+	for i := len(m.migrations) - 1; i >= 0 && n > 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.down == "" {
+			return fmt.Errorf("Migration version %d (%s) has no down file", migration.Version, migration.Name)
+		}
+
+		err = m.db.WithTransaction(ctx, func(tx database.Tx) error {
+			if _, err := tx.Exec(ctx, migration.down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, remove, migration.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf(ErrorFailedMigrate, migration.Version, migration.Name, err)
+		}
+		n--
+	}
+	return nil
+}
+
+// Status return every known migration in version order with its applied time
+func (m *migrator) Status(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]Migration, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i] = Migration{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status[i].Applied = true
+			status[i].AppliedAt = &appliedAt
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"github.com/jmoiron/sqlx"
+	_ "github.com/microsoft/go-mssqldb"
+)
+
+// sqlserver driver takes @p1 placeholder, sqlx already bind it as AT.
+// legacy mssql driver convert ? placeholder itself so query is passed as is
+func init() {
+	sqlx.BindDriver("mssql", sqlx.QUESTION)
+}
+
+func isSQLServer(driver string) bool {
+	return driver == "sqlserver" || driver == "mssql"
+}
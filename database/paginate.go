@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type PageRequest struct {
+	// maximum number of items
+	// by default 20
+	Limit int
+
+	// number of skipped items for offset pagination
+	Offset int
+
+	// use keyset pagination, items after Cursor are returned instead of using Offset
+	Keyset bool
+
+	// NextCursor of previous page for keyset pagination, empty for the first page
+	Cursor string
+
+	// column to order by, it must be unique for keyset pagination, eg: id
+	OrderBy string
+
+	// order descending
+	Desc bool
+}
+
+type PageResult[T any] struct {
+	Items []T
+
+	// cursor for the next page of keyset pagination, empty when there is no next page
+	NextCursor string
+
+	// total number of items of offset pagination, keyset pagination does not count
+	Total int64
+
+	HasMore bool
+}
+
+var (
+	ErrInvalidOrderBy = errors.New("Pagination OrderBy must be a column name")
+	ErrInvalidCursor  = errors.New("Pagination cursor is invalid")
+
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Paginate run query with ? placeholder for one page, query is wrapped as subquery so it must not have
+// its own ORDER BY or LIMIT, eg: Paginate[User](ctx, db, "SELECT * FROM users WHERE status = ?", req, status)
+func Paginate[T any](ctx context.Context, db DB, query string, req PageRequest, args ...interface{}) (PageResult[T], error) {
+	var result PageResult[T]
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.OrderBy == "" || !identifierPattern.MatchString(req.OrderBy) {
+		return result, ErrInvalidOrderBy
+	}
+
+	direction, comparison := " ASC", " > ?"
+	if req.Desc {
+		direction, comparison = " DESC", " < ?"
+	}
+
+	pageQuery := "SELECT * FROM (" + query + ") page"
+	pageArgs := append([]interface{}{}, args...)
+	if req.Keyset && req.Cursor != "" {
+		value, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return result, err
+		}
+		pageQuery += " WHERE " + req.OrderBy + comparison
+		pageArgs = append(pageArgs, value)
+	}
+
+	// fetch one more item to know whether next page exist
+	pageQuery += " ORDER BY " + req.OrderBy + direction + " LIMIT " + strconv.Itoa(req.Limit+1)
+	if !req.Keyset && req.Offset > 0 {
+		pageQuery += " OFFSET " + strconv.Itoa(req.Offset)
+	}
+
+	if err := db.Select(ctx, &result.Items, db.Rebind(pageQuery), pageArgs...); err != nil {
+		return result, err
+	}
+	if len(result.Items) > req.Limit {
+		result.HasMore = true
+		result.Items = result.Items[:req.Limit]
+	}
+
+	if req.Keyset {
+		if result.HasMore {
+			cursor, err := encodeCursor(result.Items[len(result.Items)-1], req.OrderBy)
+			if err != nil {
+				return result, err
+			}
+			result.NextCursor = cursor
+		}
+		return result, nil
+	}
+
+	countQuery := db.Rebind("SELECT COUNT(*) FROM (" + query + ") page")
+	if err := db.Get(ctx, &result.Total, countQuery, args...); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// encodeCursor encode value of db column of item
+func encodeCursor(item interface{}, column string) (string, error) {
+	v := reflect.Indirect(reflect.ValueOf(item))
+	columns, fields := structColumns(v.Type())
+	for i, name := range columns {
+		if name == column {
+			value, err := json.Marshal(v.FieldByIndex(fields[i]).Interface())
+			if err != nil {
+				return "", err
+			}
+			return base64.RawURLEncoding.EncodeToString(value), nil
+		}
+	}
+	return "", ErrInvalidOrderBy
+}
+
+func decodeCursor(cursor string) (interface{}, error) {
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var decoded interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(value)))
+	decoder.UseNumber()
+	if err = decoder.Decode(&decoded); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	switch decoded := decoded.(type) {
+	case json.Number:
+		return decoded.String(), nil
+	case string, bool:
+		return decoded, nil
+	}
+	return nil, ErrInvalidCursor
+}
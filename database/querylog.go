@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+// DefaultMaskedParams column names whose value is masked by query log
+var DefaultMaskedParams = []string{"password", "token", "card_number"}
+
+const maskedValue = "*****"
+
+var (
+	insertPattern  = regexp.MustCompile(`(?is)^\s*(?:insert|replace)\s+into\s+[\w."` + "`" + `]+\s*\(([^)]*)\)\s*values`)
+	comparePattern = regexp.MustCompile(`(?i)([\w."` + "`" + `]+)\s*(?:=|<>|!=|<=|>=|<|>|\blike|\bin\s*\()\s*$`)
+)
+
+type queryLogHook struct {
+	maskedParams []string
+	logger       log.ILogger
+}
+
+// NewQueryLogHook create hook logging every query with its args, value of column containing one of
+// maskedParams (case insensitive) is masked, nil logger use the package log
+func NewQueryLogHook(maskedParams []string, logger log.ILogger) Hook {
+	masked := make([]string, len(maskedParams))
+	for i, v := range maskedParams {
+		masked[i] = strings.ToLower(v)
+	}
+	return &queryLogHook{maskedParams: masked, logger: logger}
+}
+
+func (h *queryLogHook) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	return ctx
+}
+
+func (h *queryLogHook) AfterQuery(ctx context.Context, query string, args []interface{}, err error, duration time.Duration) {
+	logger := log.WithContext(ctx)
+	if h.logger != nil {
+		logger = h.logger.WithContext(ctx)
+	}
+	logger = logger.WithFields(log.Fields{
+		"query":     query,
+		"args":      MaskArgs(query, args, h.maskedParams),
+		"operation": Operation(ctx),
+		"duration":  duration.String(),
+	})
+
+	if err != nil && err != ErrNoRows {
+		logger.Errorf("Database query failed. Error: %s", err)
+		return
+	}
+	logger.Info("Database query")
+}
+
+// MaskArgs return copy of args where value bound to column matching maskedParams is replaced,
+// column is detected from comparison (password = ?) and INSERT column list
+func MaskArgs(query string, args []interface{}, maskedParams []string) []interface{} {
+	masked := make([]interface{}, len(args))
+	copy(masked, args)
+
+	for index, column := range paramColumns(query) {
+		if index >= len(masked) {
+			continue
+		}
+		column = strings.ToLower(column)
+		for _, param := range maskedParams {
+			if strings.Contains(column, strings.ToLower(param)) {
+				masked[index] = maskedValue
+				break
+			}
+		}
+	}
+	return masked
+}
+
+// paramColumns map placeholder index to column it is bound to, ?, $n and @pn placeholders are supported
+func paramColumns(query string) map[int]string {
+	columns := map[int]string{}
+
+	var insertColumns []string
+	valuesEnd := -1
+	if m := insertPattern.FindStringSubmatchIndex(query); m != nil {
+		for _, v := range strings.Split(query[m[2]:m[3]], ",") {
+			insertColumns = append(insertColumns, columnName(v))
+		}
+		valuesEnd = m[1]
+	}
+
+	position := 0
+	for i := 0; i < len(query); i++ {
+		var index int
+		switch c := query[i]; {
+		case c == '\'':
+			// skip string literal, '' is escaped quote
+			for i++; i < len(query) && query[i] != '\''; i++ {
+			}
+			continue
+		case c == '?':
+			index = position
+			position++
+		case c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			end := i + 1
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			n, _ := strconv.Atoi(query[i+1 : end])
+			index = n - 1
+		case c == '@' && i+2 < len(query) && (query[i+1] == 'p' || query[i+1] == 'P') && query[i+2] >= '0' && query[i+2] <= '9':
+			end := i + 2
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			n, _ := strconv.Atoi(query[i+2 : end])
+			index = n - 1
+		default:
+			continue
+		}
+
+		if m := comparePattern.FindStringSubmatch(query[:i]); m != nil {
+			columns[index] = columnName(m[1])
+		} else if len(insertColumns) > 0 && i > valuesEnd {
+			columns[index] = insertColumns[index%len(insertColumns)]
+		}
+	}
+	return columns
+}
+
+// columnName strip table qualifier and quote from identifier
+func columnName(identifier string) string {
+	identifier = strings.TrimSpace(identifier)
+	if i := strings.LastIndex(identifier, "."); i >= 0 {
+		identifier = identifier[i+1:]
+	}
+	return strings.Trim(identifier, "\"`")
+}
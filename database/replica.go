@@ -0,0 +1,254 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+// ReplicaSet route reads (Get, Select, NamedGet, NamedSelect, Queryx, BeginReadOnly) to healthy replicas with round robin,
+// everything else including transactions goes to the embedded primary
+type ReplicaSet struct {
+	*Database
+	replicas  []*replica
+	next      uint64
+	done      chan struct{}
+	closeOnce *sync.Once
+
+	// applied to replica picked by reader, see WithTimeout
+	timeout time.Duration
+
+	maxLag time.Duration
+
+	// bound of every replica check, so hanging replica is marked unhealthy instead of blocking health check
+	checkTimeout time.Duration
+}
+
+type replica struct {
+	db      *Database
+	healthy int32
+}
+
+type ReplicaOption func(*replicaOptions)
+
+type replicaOptions struct {
+	healthCheckInterval time.Duration
+	maxLag              time.Duration
+}
+
+type primaryKey struct{}
+
+const defaultHealthCheckInterval = 5 * time.Second
+
+// WithHealthCheckInterval set replica ping interval, replica not answering within interval is unhealthy,
+// by default 5 seconds
+func WithHealthCheckInterval(interval time.Duration) ReplicaOption {
+	return func(o *replicaOptions) {
+		o.healthCheckInterval = interval
+	}
+}
+
+// WithMaxReplicationLag skip replica lagging behind primary more than maxLag on health check,
+// lag is measured with replay timestamp on postgres and Seconds_Behind_Master on mysql
+// by default replication lag is not checked
+func WithMaxReplicationLag(maxLag time.Duration) ReplicaOption {
+	return func(o *replicaOptions) {
+		o.maxLag = maxLag
+	}
+}
+
+// WithPrimary force reads using ctx to go to primary, eg: read-after-write
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func isPrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryKey{}).(bool)
+	return forced
+}
+
+// ConnectWithReplicas connect to primary and replicas, unreachable replica is skipped until
+// its health check succeed, reads fallback to primary when no replica is healthy
+func ConnectWithReplicas(primaryCfg Config, replicaCfgs []Config, opts ...ReplicaOption) (DB, error) {
+	options := replicaOptions{healthCheckInterval: defaultHealthCheckInterval}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	primary, err := Connect(primaryCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &ReplicaSet{
+		Database:  primary.(*Database),
+		done:      make(chan struct{}),
+		closeOnce: &sync.Once{},
+		maxLag:    options.maxLag,
+	}
+	rs.checkTimeout = options.healthCheckInterval
+	if rs.checkTimeout <= 0 {
+		rs.checkTimeout = defaultHealthCheckInterval
+	}
+	for _, cfg := range replicaCfgs {
+		db, err := open(cfg)
+		if err != nil {
+			rs.closeAll()
+			return nil, err
+		}
+		rs.replicas = append(rs.replicas, &replica{db: db})
+	}
+
+	rs.checkReplicas()
+	if len(rs.replicas) > 0 && options.healthCheckInterval > 0 {
+		go rs.healthCheck(options.healthCheckInterval)
+	}
+
+	return rs, nil
+}
+
+func (rs *ReplicaSet) closeAll() {
+	rs.Database.connection.Close()
+	for _, r := range rs.replicas {
+		r.db.connection.Close()
+	}
+}
+
+func (rs *ReplicaSet) healthCheck(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rs.done:
+			return
+		case <-ticker.C:
+			rs.checkReplicas()
+		}
+	}
+}
+
+// checkReplicas check every replica concurrently, replica which does not answer within checkTimeout is unhealthy
+func (rs *ReplicaSet) checkReplicas() {
+	var wg sync.WaitGroup
+	for i, r := range rs.replicas {
+		wg.Add(1)
+		go func(i int, r *replica) {
+			defer wg.Done()
+			rs.checkReplica(i, r)
+		}(i, r)
+	}
+	wg.Wait()
+}
+
+func (rs *ReplicaSet) checkReplica(i int, r *replica) {
+	ctx, cancel := context.WithTimeout(context.Background(), rs.checkTimeout)
+	defer cancel()
+
+	var healthy int32
+	err := r.db.connection.PingContext(ctx)
+	if err == nil && rs.maxLag > 0 {
+		var lag time.Duration
+		if lag, err = replicationLag(ctx, r.db); err == nil && lag > rs.maxLag {
+			err = fmt.Errorf("Replication lag %s exceed %s", lag, rs.maxLag)
+		}
+	}
+	if err == nil {
+		healthy = 1
+	}
+	if atomic.SwapInt32(&r.healthy, healthy) != healthy {
+		fields := log.Fields{"replica": i, "healthy": healthy == 1}
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		log.WithFields(fields).Info("Database replica health changed")
+	}
+}
+
+// replicationLag measure how far replica is behind primary, stopped replication is reported as error
+func replicationLag(ctx context.Context, db *Database) (time.Duration, error) {
+	switch db.connection.DriverName() {
+	case "postgres":
+		// replay timestamp does not move while primary is idle, caught up replica has no lag
+		var seconds float64
+		err := db.connection.GetContext(ctx, &seconds, `SELECT CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END`)
+		return time.Duration(seconds * float64(time.Second)), err
+	case "mysql":
+		rows, err := db.connection.QueryxContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		if !rows.Next() {
+			return 0, errors.New("Database is not a replica")
+		}
+		status := map[string]interface{}{}
+		if err = rows.MapScan(status); err != nil {
+			return 0, err
+		}
+		behind, ok := status["Seconds_Behind_Master"].([]byte)
+		if !ok {
+			return 0, errors.New("Replication is not running")
+		}
+		seconds, err := strconv.ParseInt(string(behind), 10, 64)
+		return time.Duration(seconds) * time.Second, err
+	}
+	return 0, fmt.Errorf("Replication lag is not supported by %s driver", db.connection.DriverName())
+}
+
+// reader pick healthy replica with round robin, primary is returned when no replica is healthy
+func (rs *ReplicaSet) reader(ctx context.Context) *Database {
+	if isPrimaryForced(ctx) || len(rs.replicas) == 0 {
+		return rs.Database
+	}
+
+	start := atomic.AddUint64(&rs.next, 1)
+	for i := 0; i < len(rs.replicas); i++ {
+		r := rs.replicas[(start+uint64(i))%uint64(len(rs.replicas))]
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			return r.db.withTimeout(rs.timeout)
+		}
+	}
+	return rs.Database
+}
+
+func (rs *ReplicaSet) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return rs.reader(ctx).Get(ctx, dest, query, args...)
+}
+
+func (rs *ReplicaSet) NamedGet(ctx context.Context, dest interface{}, query string, arg interface{}) error {
+	return rs.reader(ctx).NamedGet(ctx, dest, query, arg)
+}
+
+func (rs *ReplicaSet) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return rs.reader(ctx).Select(ctx, dest, query, args...)
+}
+
+func (rs *ReplicaSet) NamedSelect(ctx context.Context, dest interface{}, query string, arg interface{}) error {
+	return rs.reader(ctx).NamedSelect(ctx, dest, query, arg)
+}
+
+func (rs *ReplicaSet) Queryx(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return rs.reader(ctx).Queryx(ctx, query, args...)
+}
+
+func (rs *ReplicaSet) SelectMaps(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	return rs.reader(ctx).SelectMaps(ctx, query, args...)
+}
+
+func (rs *ReplicaSet) Each(ctx context.Context, query string, args []interface{}, fn func(row Row) error) error {
+	return rs.reader(ctx).Each(ctx, query, args, fn)
+}
+
+// BeginReadOnly start read-only transaction on replica, use WithPrimary to start it on primary
+func (rs *ReplicaSet) BeginReadOnly(ctx context.Context) (Tx, error) {
+	return rs.reader(ctx).BeginReadOnly(ctx)
+}
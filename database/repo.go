@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type RepoOption func(*repoOptions)
+
+type repoOptions struct {
+	idColumn         string
+	softDeleteColumn string
+}
+
+var ErrRepoNoIDColumn = errors.New("Repo struct has no db tagged field for id column")
+
+// WithIDColumn set primary key column, by default id
+func WithIDColumn(column string) RepoOption {
+	return func(o *repoOptions) {
+		o.idColumn = column
+	}
+}
+
+// WithSoftDelete mark row deleted by setting nullable timestamp column instead of deleting it,
+// soft deleted rows are excluded from Find and Update, eg: deleted_at
+func WithSoftDelete(column string) RepoOption {
+	return func(o *repoOptions) {
+		o.softDeleteColumn = column
+	}
+}
+
+// Repo CRUD of single table with columns taken from db tags of T,
+// queries join transaction attached to ctx by ContextWithTx
+type Repo[T any] struct {
+	db      DB
+	table   string
+	options repoOptions
+
+	// columns written by Insert and Update, soft delete column is excluded
+	columns []string
+	fields  [][]int
+	idField []int
+}
+
+// driverNamer is implemented by Database to pick dialect of generated query
+type driverNamer interface {
+	DriverName() string
+}
+
+// DriverName return name of sql driver, eg: postgres or sqlserver
+func (db *Database) DriverName() string {
+	return db.connection.DriverName()
+}
+
+// NewRepo create repository of table, eg: NewRepo[User](db, "users", WithSoftDelete("deleted_at"))
+func NewRepo[T any](db DB, table string, opts ...RepoOption) *Repo[T] {
+	options := repoOptions{idColumn: "id"}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	repo := &Repo[T]{db: db, table: table, options: options}
+	columns, fields := structColumns(indirectType(reflect.TypeOf((*T)(nil)).Elem()))
+	for i, column := range columns {
+		switch column {
+		case options.idColumn:
+			repo.idField = fields[i]
+		case options.softDeleteColumn:
+		default:
+			repo.columns = append(repo.columns, column)
+			repo.fields = append(repo.fields, fields[i])
+		}
+	}
+	return repo
+}
+
+func (r *Repo[T]) driver() string {
+	if d, ok := r.db.(driverNamer); ok {
+		return d.DriverName()
+	}
+	return ""
+}
+
+// notDeleted return condition excluding soft deleted rows
+func (r *Repo[T]) notDeleted() string {
+	if r.options.softDeleteColumn == "" {
+		return ""
+	}
+	return " AND " + r.options.softDeleteColumn + " IS NULL"
+}
+
+func (r *Repo[T]) selectQuery() string {
+	columns := append([]string{r.options.idColumn}, r.columns...)
+	return fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), r.table)
+}
+
+// Insert insert obj, zero id is left to database default and filled back into obj
+// with RETURNING on postgres or last insert id on other drivers, error of driver not supporting
+// last insert id is returned
+func (r *Repo[T]) Insert(ctx context.Context, obj *T) error {
+	if r.idField == nil {
+		return ErrRepoNoIDColumn
+	}
+	v := reflect.ValueOf(obj).Elem()
+	id := v.FieldByIndex(r.idField)
+
+	columns, args := r.columns, make([]interface{}, 0, len(r.fields)+1)
+	if !id.IsZero() {
+		columns = append([]string{r.options.idColumn}, columns...)
+		args = append(args, id.Interface())
+	}
+	for _, index := range r.fields {
+		args = append(args, v.FieldByIndex(index).Interface())
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", r.table, strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	if !id.IsZero() {
+		_, err := r.db.Runner(ctx).Exec(ctx, r.db.Rebind(query), args...)
+		return err
+	}
+
+	if r.driver() == "postgres" {
+		query += " RETURNING " + r.options.idColumn
+		// Get is routed to replica on ReplicaSet
+		return r.db.Runner(ctx).Get(WithPrimary(ctx), id.Addr().Interface(), r.db.Rebind(query), args...)
+	}
+	result, err := r.db.Runner(ctx).Exec(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	switch id.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		lastID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		id.SetInt(lastID)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		lastID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		id.SetUint(uint64(lastID))
+	}
+	return nil
+}
+
+// Update overwrite every column of row with id of obj, ErrNoRows is returned when row does not exist
+// or is soft deleted on drivers reporting matched rows, mysql only report changed rows so it is not checked
+func (r *Repo[T]) Update(ctx context.Context, obj *T) error {
+	if r.idField == nil {
+		return ErrRepoNoIDColumn
+	}
+	v := reflect.ValueOf(obj).Elem()
+
+	sets := make([]string, len(r.columns))
+	args := make([]interface{}, 0, len(r.fields)+1)
+	for i, column := range r.columns {
+		sets[i] = column + " = ?"
+		args = append(args, v.FieldByIndex(r.fields[i]).Interface())
+	}
+	args = append(args, v.FieldByIndex(r.idField).Interface())
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?%s", r.table, strings.Join(sets, ", "), r.options.idColumn, r.notDeleted())
+	result, err := r.db.Runner(ctx).Exec(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 && r.driver() != "mysql" {
+		return ErrNoRows
+	}
+	return nil
+}
+
+// DeleteByID delete row or mark it deleted when soft delete is enabled
+func (r *Repo[T]) DeleteByID(ctx context.Context, id interface{}) error {
+	if r.options.softDeleteColumn == "" {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", r.table, r.options.idColumn)
+		_, err := r.db.Runner(ctx).Exec(ctx, r.db.Rebind(query), id)
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?%s", r.table, r.options.softDeleteColumn, r.options.idColumn, r.notDeleted())
+	_, err := r.db.Runner(ctx).Exec(ctx, r.db.Rebind(query), time.Now(), id)
+	return err
+}
+
+// FindByID return ErrNoRows when row does not exist or is soft deleted
+func (r *Repo[T]) FindByID(ctx context.Context, id interface{}) (T, error) {
+	var obj T
+	query := fmt.Sprintf("%s WHERE %s = ?%s", r.selectQuery(), r.options.idColumn, r.notDeleted())
+	err := r.db.Runner(ctx).Get(ctx, &obj, r.db.Rebind(query), id)
+	return obj, err
+}
+
+// FindWhere return rows matching condition with ? placeholder, eg: FindWhere(ctx, "status = ? AND age > ?", status, age)
+func (r *Repo[T]) FindWhere(ctx context.Context, where string, args ...interface{}) ([]T, error) {
+	var objs []T
+	query := fmt.Sprintf("%s WHERE (%s)%s", r.selectQuery(), where, r.notDeleted())
+	err := r.db.Runner(ctx).Select(ctx, &objs, r.db.Rebind(query), args...)
+	return objs, err
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type RetryConfig struct {
+	// maximum attempts including the first one
+	// by default query is not retried
+	MaxAttempts int
+
+	// wait before the first retry, doubled on every next retry
+	// by default 50 milliseconds
+	Backoff time.Duration
+}
+
+// IsTransient check err is temporary failure which is safe to retry read outside transaction,
+// eg: bad connection, connection reset and deadlock
+func IsTransient(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		IsDeadlock(err)
+}
+
+// isExecRetryable check Exec failed before statement was run (driver.ErrBadConn) or was rolled back by deadlock,
+// invalid connection and connection reset may happen after server applied the statement, eg: INSERT twice
+func isExecRetryable(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || IsDeadlock(err)
+}
+
+// maxTxAttempts number of attempts of ExecuteTx before serialization failure is returned
+const maxTxAttempts = 10
+
+// withRetry call fn until it succeed, return error which is not retryable or attempts are exhausted
+func (db *Database) withRetry(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	release, err := db.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	return retry(ctx, db.retry.MaxAttempts, db.retry.Backoff, retryable, fn)
+}
+
+// retry call fn until it succeed, return error which is not retryable or attempts are exhausted,
+// waiting is cut short when ctx is done
+func retry(ctx context.Context, maxAttempts int, backoff time.Duration, retryable func(error) bool, fn func() error) error {
+	if backoff <= 0 {
+		backoff = 50 * time.Millisecond
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= maxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// ExecuteTx run fn inside transaction like WithTransaction, the whole transaction is retried with backoff
+// when it fail with serialization failure (SQLSTATE 40001), eg: contention in cockroachdb.
+// fn may be called more than once, so it must not have side effect outside the transaction
+func (db *Database) ExecuteTx(ctx context.Context, fn func(tx Tx) error) error {
+	return retry(ctx, maxTxAttempts, db.retry.Backoff, IsSerializationFailure, func() error {
+		return db.WithTransaction(ctx, fn)
+	})
+}
+
+func (db *Database) exec(ctx context.Context, operation, query string, args []interface{}) (result sql.Result, err error) {
+	err = db.withRetry(ctx, isExecRetryable, func() error {
+		queryer, release := db.queryer(ctx, query)
+		defer release()
+		result, err = hookedExec(ctx, queryer, db.hooks, operation, query, args)
+		return err
+	})
+	return result, err
+}
+
+func (db *Database) get(ctx context.Context, operation string, dest interface{}, query string, args []interface{}) error {
+	return db.withRetry(ctx, IsTransient, func() error {
+		queryer, release := db.queryer(ctx, query)
+		defer release()
+		return hookedGet(ctx, queryer, db.hooks, operation, dest, query, args)
+	})
+}
+
+// selectx truncate dest before every attempt, so rows scanned by failed attempt are not duplicated
+func (db *Database) selectx(ctx context.Context, operation string, dest interface{}, query string, args []interface{}) error {
+	return db.withRetry(ctx, IsTransient, func() error {
+		if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice {
+			v.Elem().SetLen(0)
+		}
+		queryer, release := db.queryer(ctx, query)
+		defer release()
+		return hookedSelect(ctx, queryer, db.hooks, operation, dest, query, args)
+	})
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type ScriptOption func(*scriptOptions)
+
+type scriptOptions struct {
+	inTransaction bool
+}
+
+// ScriptInTransaction run every statement of script inside one transaction,
+// note mysql commit DDL statement implicitly so it can not be rolled back
+func ScriptInTransaction() ScriptOption {
+	return func(o *scriptOptions) {
+		o.inTransaction = true
+	}
+}
+
+// ExecScript execute semicolon separated statements one by one, eg: seed or fixture file,
+// statements are sent as is without rebind and execution stop at first failing statement
+func (db *Database) ExecScript(ctx context.Context, script string, opts ...ScriptOption) error {
+	var options scriptOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.inTransaction {
+		return db.WithTransaction(ctx, func(tx Tx) error {
+			return tx.ExecScript(ctx, script)
+		})
+	}
+
+	release, err := db.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	return execScript(ctx, func(ctx context.Context, query string) error {
+		_, err := hookedExec(ctx, db.connection, db.hooks, "ExecScript", query, nil)
+		return err
+	}, script)
+}
+
+// ExecScript execute statements inside the transaction, opts is ignored
+func (tx *DBTransaction) ExecScript(ctx context.Context, script string, opts ...ScriptOption) error {
+	return execScript(ctx, func(ctx context.Context, query string) error {
+		_, err := hookedExec(ctx, tx.transaction, tx.hooks, "ExecScript", query, nil)
+		return err
+	}, script)
+}
+
+func execScript(ctx context.Context, exec func(ctx context.Context, query string) error, script string) error {
+	for i, statement := range SplitStatements(script) {
+		if err := exec(ctx, statement); err != nil {
+			return fmt.Errorf("Failed to execute statement %d of script. Error: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// SplitStatements split script on semicolon outside of quoted string, identifier, comment
+// and postgres dollar quoted body, statement containing only comments is dropped
+func SplitStatements(script string) []string {
+	var statements []string
+	start, hasCode := 0, false
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(script, i, c)
+			hasCode = true
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case c == '$':
+			i = skipDollarQuoted(script, i)
+			hasCode = true
+		case c == ';':
+			if hasCode {
+				statements = append(statements, strings.TrimSpace(script[start:i]))
+			}
+			start, hasCode = i+1, false
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			hasCode = true
+		}
+	}
+	if hasCode {
+		statements = append(statements, strings.TrimSpace(script[start:]))
+	}
+	return statements
+}
+
+// skipQuoted return index of closing quote, doubled quote and mysql backslash escape in string are skipped
+func skipQuoted(script string, start int, quote byte) int {
+	for i := start + 1; i < len(script); i++ {
+		switch script[i] {
+		case '\\':
+			if quote == '\'' {
+				i++
+			}
+		case quote:
+			if i+1 < len(script) && script[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(script)
+}
+
+// skipDollarQuoted return index of last character of closing $tag$, or start when it is not a tag, eg: $1 placeholder
+func skipDollarQuoted(script string, start int) int {
+	end := strings.IndexByte(script[start+1:], '$')
+	if end < 0 {
+		return start
+	}
+	tag := script[start : start+end+2]
+	for i, c := range tag[1 : len(tag)-1] {
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && !(isDigit && i > 0) {
+			return start
+		}
+	}
+	closing := strings.Index(script[start+len(tag):], tag)
+	if closing < 0 {
+		return len(script)
+	}
+	return start + 2*len(tag) + closing - 1
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// ShardFunc return shard index in [0, shards) for key
+type ShardFunc func(key string, shards int) int
+
+// ShardedDB route calls to physical database by shard key
+type ShardedDB struct {
+	shards    []*Database
+	shardFunc ShardFunc
+}
+
+var ErrNoShard = errors.New("Sharded database requires at least one config")
+
+// HashShard default shard function using fnv-1a hash of the key
+func HashShard(key string, shards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// ConnectSharded connect to every shard, nil shardFunc use HashShard
+// the order of configs define shard index, so it must stay stable across deployments
+func ConnectSharded(cfgs []Config, shardFunc ShardFunc) (*ShardedDB, error) {
+	if len(cfgs) == 0 {
+		return nil, ErrNoShard
+	}
+	if shardFunc == nil {
+		shardFunc = HashShard
+	}
+
+	sharded := &ShardedDB{shardFunc: shardFunc}
+	for i, cfg := range cfgs {
+		db, err := Connect(cfg)
+		if err != nil {
+			for _, v := range sharded.shards {
+				v.connection.Close()
+			}
+			return nil, fmt.Errorf("Failed to connect to shard %d. Error: %w", i, err)
+		}
+		sharded.shards = append(sharded.shards, db.(*Database))
+	}
+	return sharded, nil
+}
+
+// Shard return database owning key
+func (s *ShardedDB) Shard(key string) DB {
+	return s.shards[s.ShardIndex(key)]
+}
+
+func (s *ShardedDB) ShardIndex(key string) int {
+	return s.shardFunc(key, len(s.shards))
+}
+
+// Shards return every shard ordered by index
+func (s *ShardedDB) Shards() []DB {
+	shards := make([]DB, len(s.shards))
+	for i, v := range s.shards {
+		shards[i] = v
+	}
+	return shards
+}
+
+// ExecOnAll execute query on every shard concurrently, eg: schema change
+// results are ordered by shard index, error contains every failing shard
+func (s *ShardedDB) ExecOnAll(ctx context.Context, query string, args ...interface{}) ([]sql.Result, error) {
+	results := make([]sql.Result, len(s.shards))
+	errs := make([]error, len(s.shards))
+
+	var wg sync.WaitGroup
+	for i, db := range s.shards {
+		wg.Add(1)
+		go func(i int, db *Database) {
+			defer wg.Done()
+			results[i], errs[i] = db.Exec(ctx, query, args...)
+		}(i, db)
+	}
+	wg.Wait()
+
+	return results, shardErrors(errs)
+}
+
+// Ping ping every shard
+func (s *ShardedDB) Ping() error {
+	errs := make([]error, len(s.shards))
+	for i, db := range s.shards {
+		errs[i] = db.Ping()
+	}
+	return shardErrors(errs)
+}
+
+// Close close every shard concurrently, see Database.Close
+func (s *ShardedDB) Close(ctx context.Context) error {
+	errs := make([]error, len(s.shards))
+	var wg sync.WaitGroup
+	for i, db := range s.shards {
+		wg.Add(1)
+		go func(i int, db *Database) {
+			defer wg.Done()
+			errs[i] = db.Close(ctx)
+		}(i, db)
+	}
+	wg.Wait()
+	return shardErrors(errs)
+}
+
+func shardErrors(errs []error) error {
+	failed := &ManagerError{Errors: map[string]error{}}
+	for i, err := range errs {
+		if err != nil {
+			failed.Errors[fmt.Sprintf("shard %d", i)] = err
+		}
+	}
+	if len(failed.Errors) > 0 {
+		return failed
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+type slowQueryHook struct {
+	threshold time.Duration
+	logger    log.ILogger
+}
+
+// NewSlowQueryHook create hook logging sanitized query, duration and caller of query slower than threshold,
+// nil logger use the package log
+func NewSlowQueryHook(threshold time.Duration, logger log.ILogger) Hook {
+	return &slowQueryHook{threshold: threshold, logger: logger}
+}
+
+func (h *slowQueryHook) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	return ctx
+}
+
+func (h *slowQueryHook) AfterQuery(ctx context.Context, query string, args []interface{}, err error, duration time.Duration) {
+	if duration < h.threshold {
+		return
+	}
+
+	logger := log.WithContext(ctx)
+	if h.logger != nil {
+		logger = h.logger.WithContext(ctx)
+	}
+	logger.WithFields(log.Fields{
+		"query":     SanitizeQuery(query),
+		"operation": Operation(ctx),
+		"duration":  duration.String(),
+		"caller":    queryCaller(),
+	}).Info("Slow database query")
+}
+
+// queryCaller return file:line of the first frame outside this package and database/sql
+func queryCaller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isInternalFrame(frame.Function) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func isInternalFrame(function string) bool {
+	return strings.HasPrefix(function, tracerName+".") ||
+		strings.HasPrefix(function, "github.com/jmoiron/sqlx.") ||
+		strings.HasPrefix(function, "database/sql.")
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type StmtCacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+// stmtCache keep the most recently used prepared statements keyed by query text
+type stmtCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	hits     uint64
+	misses   uint64
+}
+
+// cachedStmt is closed when it is evicted and no caller is using it
+type cachedStmt struct {
+	query   string
+	stmt    *sqlx.Stmt
+	refs    int
+	evicted bool
+}
+
+type queryExecer interface {
+	sqlx.ExecerContext
+	sqlx.QueryerContext
+}
+
+// stmtQueryer run query through prepared statement, query argument is ignored
+type stmtQueryer struct {
+	stmt *sqlx.Stmt
+}
+
+func newStmtCache(capacity int) *stmtCache {
+	return &stmtCache{
+		capacity: capacity,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// get return prepared statement of query, statement is prepared on miss and the least recently used one is evicted,
+// release must be called when caller is done with the statement
+func (c *stmtCache) get(ctx context.Context, connection *sqlx.DB, query string) (*cachedStmt, error) {
+	c.mu.Lock()
+	if elem, ok := c.items[query]; ok {
+		c.order.MoveToFront(elem)
+		cached := elem.Value.(*cachedStmt)
+		cached.refs++
+		c.mu.Unlock()
+		atomic.AddUint64(&c.hits, 1)
+		return cached, nil
+	}
+	c.mu.Unlock()
+	atomic.AddUint64(&c.misses, 1)
+
+	stmt, err := connection.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[query]; ok {
+		// prepared concurrently by another caller
+		stmt.Close()
+		c.order.MoveToFront(elem)
+		cached := elem.Value.(*cachedStmt)
+		cached.refs++
+		return cached, nil
+	}
+
+	cached := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.items[query] = c.order.PushFront(cached)
+	for c.order.Len() > c.capacity {
+		c.evict(c.order.Back())
+	}
+	return cached, nil
+}
+
+func (c *stmtCache) release(cached *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached.refs--
+	if cached.evicted && cached.refs == 0 {
+		cached.stmt.Close()
+	}
+}
+
+func (c *stmtCache) evict(elem *list.Element) {
+	cached := elem.Value.(*cachedStmt)
+	c.order.Remove(elem)
+	delete(c.items, cached.query)
+	cached.evicted = true
+	if cached.refs == 0 {
+		cached.stmt.Close()
+	}
+}
+
+func (c *stmtCache) stats() StmtCacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+	return StmtCacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Size:   size,
+	}
+}
+
+// close close every cached statement
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.order.Len() > 0 {
+		c.evict(c.order.Back())
+	}
+}
+
+// queryer return cached prepared statement of query when cache is enabled,
+// the connection is used when cache is disabled or prepare fail. release must be called after query is done
+func (db *Database) queryer(ctx context.Context, query string) (queryer queryExecer, release func()) {
+	if db.stmts == nil {
+		return db.connection, func() {}
+	}
+	cached, err := db.stmts.get(ctx, db.connection, query)
+	if err != nil {
+		return db.connection, func() {}
+	}
+	return &stmtQueryer{stmt: cached.stmt}, func() { db.stmts.release(cached) }
+}
+
+// StmtCacheStats return hit and miss counter of prepared statement cache, zero when cache is disabled
+func (db *Database) StmtCacheStats() StmtCacheStats {
+	if db.stmts == nil {
+		return StmtCacheStats{}
+	}
+	return db.stmts.stats()
+}
+
+func (q *stmtQueryer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return q.stmt.ExecContext(ctx, args...)
+}
+
+func (q *stmtQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return q.stmt.QueryContext(ctx, args...)
+}
+
+func (q *stmtQueryer) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return q.stmt.QueryxContext(ctx, args...)
+}
+
+func (q *stmtQueryer) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return q.stmt.QueryRowxContext(ctx, args...)
+}
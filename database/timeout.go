@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+type cancelKey struct{}
+
+// timeoutHook bound every query with deadline independent of caller context,
+// it is registered last so tracing and logging observe the deadline error
+type timeoutHook struct {
+	timeout time.Duration
+}
+
+func (h *timeoutHook) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	return context.WithValue(ctx, cancelKey{}, cancel)
+}
+
+func (h *timeoutHook) AfterQuery(ctx context.Context, query string, args []interface{}, err error, duration time.Duration) {
+	// rows of Queryx and NamedQueryRowx are read after the hook, they are released by the deadline instead
+	switch Operation(ctx) {
+	case "Queryx", "NamedQueryRowx":
+		return
+	}
+	if cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+// WithTimeout return DB sharing the same pool whose queries, including transactions and statements
+// started from it, are cancelled after timeout even when ctx has no deadline,
+// retried query get new deadline per attempt
+func (db *Database) WithTimeout(timeout time.Duration) DB {
+	return db.withTimeout(timeout)
+}
+
+func (db *Database) withTimeout(timeout time.Duration) *Database {
+	if timeout <= 0 {
+		return db
+	}
+	clone := *db
+	clone.hooks = append(append([]Hook{}, db.hooks...), &timeoutHook{timeout: timeout})
+	return &clone
+}
+
+// WithTimeout apply timeout to primary and replicas, replica health is shared with rs
+func (rs *ReplicaSet) WithTimeout(timeout time.Duration) DB {
+	if timeout <= 0 {
+		return rs
+	}
+	return &ReplicaSet{
+		Database:  rs.Database.withTimeout(timeout),
+		replicas:  rs.replicas,
+		done:      rs.done,
+		closeOnce: rs.closeOnce,
+		timeout:   timeout,
+		maxLag:    rs.maxLag,
+	}
+}
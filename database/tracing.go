@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/vincentwijaya/go-pkg/v1/database"
+
+// literalPattern match string and numeric literal written directly in the query, number is captured with
+// the character before it so digits of $n placeholder are kept
+var literalPattern = regexp.MustCompile(`'(?:[^']|'')*'|(^|[^$\w])\d+(?:\.\d+)?\b`)
+
+type tracingHook struct {
+	tracer trace.Tracer
+	driver string
+}
+
+// NewTracingHook create hook starting span per query as child of span in the query context,
+// span has sanitized statement, driver and rows affected of exec
+func NewTracingHook(tracer trace.Tracer, driver string) Hook {
+	return &tracingHook{tracer: tracer, driver: driver}
+}
+
+// SanitizeQuery replace literal value in query with ?, so span and log do not leak data
+func SanitizeQuery(query string) string {
+	return literalPattern.ReplaceAllString(query, "${1}?")
+}
+
+func (h *tracingHook) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	ctx, _ = h.tracer.Start(ctx, "db."+Operation(ctx),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", h.driver),
+			attribute.String("db.statement", SanitizeQuery(query)),
+			attribute.String("db.operation", Operation(ctx)),
+		),
+	)
+	return ctx
+}
+
+func (h *tracingHook) afterExec(ctx context.Context, result sql.Result) {
+	if affected, err := result.RowsAffected(); err == nil {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.rows_affected", affected))
+	}
+}
+
+func (h *tracingHook) AfterQuery(ctx context.Context, query string, args []interface{}, err error, duration time.Duration) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,33 @@
+package database
+
+import "testing"
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "SELECT * FROM users WHERE id = $1 AND status = $2",
+			want:  "SELECT * FROM users WHERE id = $1 AND status = $2",
+		},
+		{
+			query: "SELECT * FROM users WHERE name = 'o''neil' AND age > 18 LIMIT 10",
+			want:  "SELECT * FROM users WHERE name = ? AND age > ? LIMIT ?",
+		},
+		{
+			query: "SELECT * FROM t1 WHERE id IN (1,2.5) AND score = @p1",
+			want:  "SELECT * FROM t1 WHERE id IN (?,?) AND score = @p1",
+		},
+		{
+			query: "10",
+			want:  "?",
+		},
+	}
+
+	for _, test := range tests {
+		if got := SanitizeQuery(test.query); got != test.want {
+			t.Errorf("SanitizeQuery(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
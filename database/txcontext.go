@@ -0,0 +1,33 @@
+package database
+
+import "context"
+
+type txKey struct{}
+
+// ContextWithTx attach tx to ctx, so repository called with ctx join the transaction through DB.Runner
+func ContextWithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext return transaction attached by ContextWithTx
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(Tx)
+	return tx, ok
+}
+
+// Runner return transaction attached to ctx or db itself when there is none,
+// eg: db.Runner(ctx).Exec(ctx, query, args...) inside repository
+func (db *Database) Runner(ctx context.Context) Runner {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// Runner return transaction attached to ctx or rs so reads outside transaction still go to replicas
+func (rs *ReplicaSet) Runner(ctx context.Context) Runner {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return rs
+}
@@ -0,0 +1,141 @@
+package types
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+var jsonNull = []byte("null")
+
+// NullString sql.NullString marshalled to json as string or null
+type NullString struct {
+	sql.NullString
+}
+
+func NewNullString(s string) NullString {
+	return NullString{sql.NullString{String: s, Valid: true}}
+}
+
+func (n NullString) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.String)
+}
+
+func (n *NullString) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		*n = NullString{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.String); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// NullInt64 sql.NullInt64 marshalled to json as number or null
+type NullInt64 struct {
+	sql.NullInt64
+}
+
+func NewNullInt64(i int64) NullInt64 {
+	return NullInt64{sql.NullInt64{Int64: i, Valid: true}}
+}
+
+func (n NullInt64) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.Int64)
+}
+
+func (n *NullInt64) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		*n = NullInt64{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.Int64); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// NullTime sql.NullTime marshalled to json as RFC 3339 time or null
+type NullTime struct {
+	sql.NullTime
+}
+
+func NewNullTime(t time.Time) NullTime {
+	return NullTime{sql.NullTime{Time: t, Valid: true}}
+}
+
+func (n NullTime) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.Time)
+}
+
+func (n *NullTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		*n = NullTime{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.Time); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// JSONB json encoded column such as postgres json/jsonb or mysql json, NULL is scanned as zero value of T
+type JSONB[T any] struct {
+	Data T
+}
+
+func NewJSONB[T any](data T) JSONB[T] {
+	return JSONB[T]{Data: data}
+}
+
+// Value implement driver.Valuer
+func (j JSONB[T]) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, err
+	}
+	// string instead of []byte, postgres treat []byte as bytea
+	return string(b), nil
+}
+
+// Scan implement sql.Scanner
+func (j *JSONB[T]) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		var zero T
+		j.Data = zero
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("Failed to scan %T into JSONB", src)
+	}
+	return json.Unmarshal(data, &j.Data)
+}
+
+func (j JSONB[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Data)
+}
+
+func (j *JSONB[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &j.Data)
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrUpsertNotSupported = errors.New("Upsert is only supported by postgres, mysql and sqlite3 driver")
+	ErrUpsertNilObject    = errors.New("Upsert object is nil")
+)
+
+// Upsert insert obj or update it when it conflict with existing row, columns are taken from struct db tags.
+// postgres and sqlite3 use ON CONFLICT (conflictColumns) DO UPDATE, mysql use ON DUPLICATE KEY UPDATE
+// which detect conflict on any unique key so conflictColumns is only excluded from the update
+func (db *Database) Upsert(ctx context.Context, table string, obj interface{}, conflictColumns []string) (sql.Result, error) {
+	query, args, err := upsertQuery(db.connection.DriverName(), table, obj, conflictColumns)
+	if err != nil {
+		return nil, err
+	}
+	return db.exec(ctx, "Upsert", db.connection.Rebind(query), args)
+}
+
+// Upsert insert or update obj inside the transaction, see Database.Upsert
+func (tx *DBTransaction) Upsert(ctx context.Context, table string, obj interface{}, conflictColumns []string) (sql.Result, error) {
+	query, args, err := upsertQuery(tx.connection.DriverName(), table, obj, conflictColumns)
+	if err != nil {
+		return nil, err
+	}
+	return hookedExec(ctx, tx.transaction, tx.hooks, "Upsert", tx.connection.Rebind(query), args)
+}
+
+func upsertQuery(driver, table string, obj interface{}, conflictColumns []string) (string, []interface{}, error) {
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return "", nil, ErrUpsertNilObject
+	}
+	v = reflect.Indirect(v)
+	columns, fields := structColumns(v.Type())
+	if len(columns) == 0 {
+		return "", nil, ErrBulkEmptyColumns
+	}
+
+	args := make([]interface{}, len(fields))
+	for i, index := range fields {
+		args[i] = v.FieldByIndex(index).Interface()
+	}
+
+	conflict := map[string]bool{}
+	for _, column := range conflictColumns {
+		conflict[column] = true
+	}
+	var updates []string
+	for _, column := range columns {
+		if !conflict[column] {
+			updates = append(updates, column)
+		}
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+
+	switch driver {
+	case "postgres", "sqlite3":
+		if len(conflictColumns) == 0 {
+			return "", nil, errors.New("Upsert on postgres and sqlite3 requires conflict columns")
+		}
+		query += " ON CONFLICT (" + strings.Join(conflictColumns, ", ") + ")"
+		if len(updates) == 0 {
+			return query + " DO NOTHING", args, nil
+		}
+		sets := make([]string, len(updates))
+		for i, column := range updates {
+			sets[i] = column + " = EXCLUDED." + column
+		}
+		return query + " DO UPDATE SET " + strings.Join(sets, ", "), args, nil
+	case "mysql":
+		if len(updates) == 0 {
+			// no-op update so duplicate does not fail
+			updates = columns[:1]
+		}
+		sets := make([]string, len(updates))
+		for i, column := range updates {
+			sets[i] = column + " = VALUES(" + column + ")"
+		}
+		return query + " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), args, nil
+	}
+	return "", nil, ErrUpsertNotSupported
+}
@@ -0,0 +1,114 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+type Profile string
+
+const (
+	ProfileHeap      Profile = "heap"
+	ProfileGoroutine Profile = "goroutine"
+	ProfileCPU       Profile = "cpu"
+)
+
+// IStorage store captured profile, implement it to upload profiles into object storage
+type IStorage interface {
+	Store(ctx context.Context, name string, content io.Reader) error
+}
+
+type FileStorage struct {
+	dir string
+}
+
+type Capturer struct {
+	storage IStorage
+}
+
+var ErrUnknownProfile = errors.New("Unknown profile, use heap, goroutine or cpu")
+
+// NewFileStorage store profiles as files inside dir
+func NewFileStorage(dir string) IStorage {
+	return &FileStorage{dir: dir}
+}
+
+func (fs *FileStorage) Store(ctx context.Context, name string, content io.Reader) error {
+	if err := os.MkdirAll(fs.dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(fs.dir, name))
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// NewCapturer create profile capturer, nil storage write profiles into os.TempDir()
+func NewCapturer(storage IStorage) *Capturer {
+	if storage == nil {
+		storage = NewFileStorage(os.TempDir())
+	}
+	return &Capturer{storage: storage}
+}
+
+// Capture take profile and store it, duration is only used by cpu profile
+// returned name is the stored profile name, eg: cpu-myhost-20201231T235959.pprof
+func (c *Capturer) Capture(ctx context.Context, profile Profile, duration time.Duration) (string, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch profile {
+	case ProfileHeap:
+		runtime.GC()
+		err = pprof.Lookup("heap").WriteTo(&buf, 0)
+	case ProfileGoroutine:
+		err = pprof.Lookup("goroutine").WriteTo(&buf, 0)
+	case ProfileCPU:
+		err = captureCPU(ctx, &buf, duration)
+	default:
+		return "", ErrUnknownProfile
+	}
+	if err != nil {
+		return "", err
+	}
+
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("%s-%s-%s.pprof", profile, hostname, time.Now().Format("20060102T150405"))
+	if err = c.storage.Store(ctx, name, &buf); err != nil {
+		return "", err
+	}
+
+	log.WithFields(log.Fields{"profile": profile, "name": name}).Info("Profile captured")
+	return name, nil
+}
+
+func captureCPU(ctx context.Context, w io.Writer, duration time.Duration) error {
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+
+	pprof.StopCPUProfile()
+	return ctx.Err()
+}
@@ -0,0 +1,141 @@
+package diagnostics
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+type Config struct {
+	// listen address of diagnostics server, keep it separate from the service port
+	// by default 127.0.0.1:6060
+	Address string
+
+	// basic auth credential, required unless Token is set
+	Username string
+	Password string
+
+	// bearer token auth, eg: Authorization: Bearer <token>
+	Token string
+
+	// storage of captured profiles, by default profiles are written into os.TempDir()
+	Storage IStorage
+}
+
+type Server struct {
+	config  Config
+	server  *http.Server
+	capture *Capturer
+}
+
+var ErrMissingAuth = errors.New("Missing diagnostics credential, set username/password or token")
+
+// NewServer create diagnostics http server with pprof, expvar and profile capture endpoints
+func NewServer(config Config) (*Server, error) {
+	if config.Token == "" && (config.Username == "" || config.Password == "") {
+		return nil, ErrMissingAuth
+	}
+	if config.Address == "" {
+		config.Address = "127.0.0.1:6060"
+	}
+
+	s := &Server{
+		config:  config,
+		capture: NewCapturer(config.Storage),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/capture", s.handleCapture)
+
+	s.server = &http.Server{
+		Addr:    config.Address,
+		Handler: s.auth(mux),
+	}
+	return s, nil
+}
+
+// Start listen in background, error other than server closed is logged
+func (s *Server) Start() {
+	go func() {
+		log.WithField("address", s.config.Address).Info("Diagnostics server started")
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.WithField("address", s.config.Address).Errorf("Diagnostics server stopped. Error: %s", err)
+		}
+	}()
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="diagnostics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.Token != "" {
+		expected := "Bearer " + s.config.Token
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) == 1 {
+			return true
+		}
+	}
+	if s.config.Username != "" && s.config.Password != "" {
+		username, password, ok := r.BasicAuth()
+		if ok &&
+			subtle.ConstantTimeCompare([]byte(username), []byte(s.config.Username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(s.config.Password)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCapture capture profile into storage
+// eg: POST /debug/capture?profile=cpu&seconds=30
+func (s *Server) handleCapture(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	seconds := 30
+	if v := r.URL.Query().Get("seconds"); v != "" {
+		var err error
+		if seconds, err = strconv.Atoi(v); err != nil || seconds <= 0 {
+			http.Error(w, "Invalid seconds", http.StatusBadRequest)
+			return
+		}
+	}
+
+	name, err := s.capture.Capture(r.Context(), Profile(r.URL.Query().Get("profile")), time.Duration(seconds)*time.Second)
+	if err == ErrUnknownProfile {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintln(w, name)
+}
@@ -0,0 +1,48 @@
+package diagnostics
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+// DumpOnSIGQUIT log goroutine stacks on SIGQUIT instead of the default dump and exit,
+// until ctx is done
+func DumpOnSIGQUIT(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGQUIT)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				log.WithFields(log.Fields{
+					"event":      "sigquit",
+					"goroutines": runtime.NumGoroutine(),
+				}).Error(string(stacks()))
+			}
+		}
+	}()
+}
+
+// stacks return stack traces of all goroutines
+func stacks() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		if len(buf) >= 64<<20 {
+			return buf
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
@@ -0,0 +1,183 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/curl"
+)
+
+type ConsulConfig struct {
+	// consul agent address, by default http://127.0.0.1:8500
+	Address    string
+	Token      string
+	Datacenter string
+
+	// request timeout (in second), by default 5 seconds
+	Timeout int
+}
+
+type Consul struct {
+	address    string
+	token      string
+	datacenter string
+	timeout    int
+	requestor  curl.IHttpRequestor
+	counter    roundRobin
+}
+
+type consulRegistration struct {
+	ID      string       `json:"ID"`
+	Name    string       `json:"Name"`
+	Address string       `json:"Address"`
+	Port    int          `json:"Port"`
+	Tags    []string     `json:"Tags,omitempty"`
+	Check   *consulCheck `json:"Check,omitempty"`
+}
+
+type consulCheck struct {
+	HTTP                           string `json:"HTTP"`
+	Interval                       string `json:"Interval"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+type consulServiceEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+	} `json:"Service"`
+}
+
+const ErrorConsulRequest = "Failed to request consul %s. Error: %s"
+
+// NewConsul create consul registry and resolver which talk to consul agent http api
+func NewConsul(config ConsulConfig, client curl.IHttpClient) *Consul {
+	address := config.Address
+	if address == "" {
+		address = "http://127.0.0.1:8500"
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 5
+	}
+	if client == nil {
+		client = curl.NewHTTPClient()
+	}
+
+	return &Consul{
+		address:    strings.TrimSuffix(address, "/"),
+		token:      config.Token,
+		datacenter: config.Datacenter,
+		timeout:    timeout,
+		requestor:  curl.NewHttpRequestor(client),
+	}
+}
+
+func (c *Consul) newRequest(method, path string) curl.IHttpRequest {
+	uri := c.address + path
+	if c.datacenter != "" {
+		uri += "?dc=" + url.QueryEscape(c.datacenter)
+	}
+	req := c.requestor.NewHttpRequest(method, uri)
+	if c.token != "" {
+		req.SetHeader("X-Consul-Token", c.token)
+	}
+	return req
+}
+
+func (c *Consul) do(ctx context.Context, req curl.IHttpRequest) ([]byte, error) {
+	resp, err := req.Do(ctx, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf(ErrorConsulRequest, c.address, err)
+	}
+	if !resp.IsSuccess() {
+		return nil, fmt.Errorf(ErrorConsulRequest, c.address, fmt.Sprintf("status %d: %s", resp.GetStatusCode(), resp.GetBody()))
+	}
+	return resp.GetBody(), nil
+}
+
+func (c *Consul) Register(ctx context.Context, service Service) error {
+	registration := consulRegistration{
+		ID:      service.id(),
+		Name:    service.Name,
+		Address: service.Address,
+		Port:    service.Port,
+		Tags:    service.Tags,
+	}
+	if service.HealthCheckURL != "" {
+		interval := service.HealthCheckInterval
+		if interval <= 0 {
+			interval = 10
+		}
+		deregisterAfter := service.DeregisterAfter
+		if deregisterAfter <= 0 {
+			deregisterAfter = 1
+		}
+		registration.Check = &consulCheck{
+			HTTP:                           service.HealthCheckURL,
+			Interval:                       (time.Duration(interval) * time.Second).String(),
+			DeregisterCriticalServiceAfter: (time.Duration(deregisterAfter) * time.Minute).String(),
+		}
+	}
+
+	body, err := json.Marshal(registration)
+	if err != nil {
+		return err
+	}
+
+	req := c.newRequest(http.MethodPut, "/v1/agent/service/register")
+	req.SetHeader("Content-Type", "application/json")
+	req.SetBody(body)
+	_, err = c.do(ctx, req)
+	return err
+}
+
+func (c *Consul) Deregister(ctx context.Context, serviceID string) error {
+	req := c.newRequest(http.MethodPut, "/v1/agent/service/deregister/"+url.PathEscape(serviceID))
+	req.SetHeader("Content-Type", "application/json")
+	_, err := c.do(ctx, req)
+	return err
+}
+
+func (c *Consul) Resolve(ctx context.Context, name string) ([]Endpoint, error) {
+	req := c.newRequest(http.MethodGet, "/v1/health/service/"+url.PathEscape(name))
+	req.AddParam("passing", "true")
+	body, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []consulServiceEntry
+	if err = json.Unmarshal(body, &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrServiceNotFound
+	}
+
+	endpoints := make([]Endpoint, 0, len(entries))
+	for _, v := range entries {
+		address := v.Service.Address
+		if address == "" {
+			address = v.Node.Address
+		}
+		endpoints = append(endpoints, Endpoint{Address: address, Port: v.Service.Port})
+	}
+	return endpoints, nil
+}
+
+func (c *Consul) ResolveHost(ctx context.Context, name string) (string, error) {
+	endpoints, err := c.Resolve(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return c.counter.pick(name, endpoints).String(), nil
+}
@@ -0,0 +1,131 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+type IRegistry interface {
+	Register(ctx context.Context, service Service) error
+	Deregister(ctx context.Context, serviceID string) error
+}
+
+type IResolver interface {
+	// Resolve return healthy endpoints of logical service name
+	Resolve(ctx context.Context, name string) ([]Endpoint, error)
+	// ResolveHost pick one healthy endpoint (round robin) and return it in host:port format
+	ResolveHost(ctx context.Context, name string) (string, error)
+}
+
+type Endpoint struct {
+	Address string
+	Port    int
+}
+
+type Service struct {
+	// unique id of this instance, by default Name-Address-Port
+	ID      string
+	Name    string
+	Address string
+	Port    int
+	Tags    []string
+
+	// http endpoint polled by the registry, eg: http://10.0.0.1:8080/health
+	// no health check is registered when empty
+	HealthCheckURL string
+
+	// health check interval (in second), by default 10 seconds
+	HealthCheckInterval int
+
+	// deregister the service after health check failing (in minute), by default 1 minute
+	DeregisterAfter int
+}
+
+type resolver struct {
+	resolvers []IResolver
+	counter   roundRobin
+}
+
+type roundRobin struct {
+	mu       sync.Mutex
+	counters map[string]*uint64
+}
+
+var ErrServiceNotFound = errors.New("no healthy endpoint found for service")
+
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.Address, strconv.Itoa(e.Port))
+}
+
+func (s Service) id() string {
+	if s.ID != "" {
+		return s.ID
+	}
+	return s.Name + "-" + s.Address + "-" + strconv.Itoa(s.Port)
+}
+
+// NewResolver combine resolvers, the next resolver is used as fallback
+// when previous resolver fails or has no healthy endpoint
+// eg: NewResolver(consul, NewStaticResolver(cfg))
+func NewResolver(resolvers ...IResolver) IResolver {
+	return &resolver{resolvers: resolvers}
+}
+
+func (r *resolver) Resolve(ctx context.Context, name string) ([]Endpoint, error) {
+	err := ErrServiceNotFound
+	for _, v := range r.resolvers {
+		var endpoints []Endpoint
+		endpoints, err = v.Resolve(ctx, name)
+		if err == nil && len(endpoints) > 0 {
+			return endpoints, nil
+		}
+	}
+	if err == nil {
+		err = ErrServiceNotFound
+	}
+	return nil, err
+}
+
+func (r *resolver) ResolveHost(ctx context.Context, name string) (string, error) {
+	endpoints, err := r.Resolve(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return r.counter.pick(name, endpoints).String(), nil
+}
+
+func (rr *roundRobin) pick(name string, endpoints []Endpoint) Endpoint {
+	rr.mu.Lock()
+	if rr.counters == nil {
+		rr.counters = map[string]*uint64{}
+	}
+	counter, ok := rr.counters[name]
+	if !ok {
+		counter = new(uint64)
+		rr.counters[name] = counter
+	}
+	rr.mu.Unlock()
+
+	next := atomic.AddUint64(counter, 1) - 1
+	return endpoints[next%uint64(len(endpoints))]
+}
+
+// RegisterUntilDone register service on startup and deregister it once ctx is done (shutdown)
+// returned channel is closed after deregistration finished
+func RegisterUntilDone(ctx context.Context, registry IRegistry, service Service) (<-chan struct{}, error) {
+	if err := registry.Register(ctx, service); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		registry.Deregister(context.Background(), service.id())
+	}()
+	return done, nil
+}
@@ -0,0 +1,53 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"strconv"
+)
+
+type StaticConfig struct {
+	// map of logical service name to list of host:port
+	// eg: {"payment": ["10.0.0.1:8080", "10.0.0.2:8080"]}
+	Services map[string][]string
+}
+
+type StaticResolver struct {
+	services map[string][]Endpoint
+	counter  roundRobin
+}
+
+// NewStaticResolver create resolver from static config, every endpoint is considered healthy
+func NewStaticResolver(config StaticConfig) (IResolver, error) {
+	services := map[string][]Endpoint{}
+	for name, hosts := range config.Services {
+		for _, v := range hosts {
+			host, port, err := net.SplitHostPort(v)
+			if err != nil {
+				return nil, err
+			}
+			portNumber, err := strconv.Atoi(port)
+			if err != nil {
+				return nil, err
+			}
+			services[name] = append(services[name], Endpoint{Address: host, Port: portNumber})
+		}
+	}
+	return &StaticResolver{services: services}, nil
+}
+
+func (s *StaticResolver) Resolve(ctx context.Context, name string) ([]Endpoint, error) {
+	endpoints, ok := s.services[name]
+	if !ok || len(endpoints) == 0 {
+		return nil, ErrServiceNotFound
+	}
+	return endpoints, nil
+}
+
+func (s *StaticResolver) ResolveHost(ctx context.Context, name string) (string, error) {
+	endpoints, err := s.Resolve(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return s.counter.pick(name, endpoints).String(), nil
+}
@@ -0,0 +1,460 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/cache"
+)
+
+type IQueue interface {
+	Enqueue(ctx context.Context, jobType string, payload interface{}, opts EnqueueOptions) (string, error)
+	Get(ctx context.Context, id string) (*Job, error)
+	Stats(ctx context.Context) (Stats, error)
+	DeadJobs(ctx context.Context, offset, limit int) ([]Job, error)
+	RetryDead(ctx context.Context, id string) error
+	DeleteDead(ctx context.Context, id string) error
+}
+
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Priority   int             `json:"priority"`
+	MaxRetry   int             `json:"max_retry"`
+	Attempts   int             `json:"attempts"`
+	LastError  string          `json:"last_error,omitempty"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	FailedAt   *time.Time      `json:"failed_at,omitempty"`
+
+	// lease token of dequeue, complete and fail are rejected once job is reaped and leased to another worker
+	lease string
+}
+
+// Bind decode job payload into obj
+func (j *Job) Bind(obj interface{}) error {
+	return json.Unmarshal(j.Payload, obj)
+}
+
+type EnqueueOptions struct {
+	// run the job after delay, zero means immediately
+	Delay time.Duration
+
+	// higher priority job will be processed first
+	// priority must be between -100 and 100
+	Priority int
+
+	// maximum number of retry before the job moved to dead letter queue
+	// negative value means no retry, zero will use default retry (3)
+	MaxRetry int
+}
+
+type QueueConfig struct {
+	// queue name, used as redis hash tag of every queue key so scripts work on redis cluster
+	// by default queue name is "default"
+	Name string
+}
+
+type Stats struct {
+	Ready   int
+	Delayed int
+	Active  int
+	Dead    int
+}
+
+type Queue struct {
+	cache cache.ICache
+	name  string
+}
+
+const (
+	defaultQueueName = "default"
+	defaultMaxRetry  = 3
+	maxPriority      = 100
+
+	// priorityWeight keep priority ordering above enqueue time (in millisecond) in ready score
+	priorityWeight = 1e13
+)
+
+var (
+	ErrJobNotFound     = errors.New("job not found")
+	ErrInvalidPriority = fmt.Errorf("priority must be between %d and %d", -maxPriority, maxPriority)
+	ErrEmptyJobType    = errors.New("job type must not be empty")
+
+	// errLeaseExpired recorded as last error of job whose worker did not finish it within lease
+	errLeaseExpired = errors.New("lease expired before job finished")
+	// errLeaseLost returned when worker finish job which was reaped after its lease expired
+	errLeaseLost = errors.New("job lease was lost, job was requeued after lease expired")
+)
+
+// NewQueue create job queue on top of redis connection from cache package
+func NewQueue(c cache.ICache, config QueueConfig) IQueue {
+	return newQueue(c, config)
+}
+
+func newQueue(c cache.ICache, config QueueConfig) *Queue {
+	name := config.Name
+	if name == "" {
+		name = defaultQueueName
+	}
+	return &Queue{cache: c, name: name}
+}
+
+func (q *Queue) key(kind string) string {
+	return fmt.Sprintf("jobs:{%s}:%s", q.name, kind)
+}
+
+func (q *Queue) jobKey(id string) string {
+	return q.key("job:" + id)
+}
+
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts EnqueueOptions) (string, error) {
+	if jobType == "" {
+		return "", ErrEmptyJobType
+	}
+	if opts.Priority < -maxPriority || opts.Priority > maxPriority {
+		return "", ErrInvalidPriority
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+
+	maxRetry := opts.MaxRetry
+	if maxRetry == 0 {
+		maxRetry = defaultMaxRetry
+	} else if maxRetry < 0 {
+		maxRetry = 0
+	}
+
+	now := time.Now()
+	job := Job{
+		ID:         id,
+		Type:       jobType,
+		Payload:    data,
+		Priority:   opts.Priority,
+		MaxRetry:   maxRetry,
+		EnqueuedAt: now,
+	}
+	jobValue, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+
+	queue, score := q.key("ready"), readyScore(job)
+	if opts.Delay > 0 {
+		queue, score = q.key("delayed"), float64(toMillis(now.Add(opts.Delay)))
+	}
+	err = q.cache.Do(ctx, "EVAL", enqueueScript, 2, q.jobKey(id), queue, jobValue, score, id).Error()
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	err := q.cache.Get(ctx, q.jobKey(id)).Unmarshal(&job)
+	if err == cache.ErrorNil {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	counters := map[string]*int{
+		"ready":   &stats.Ready,
+		"delayed": &stats.Delayed,
+		"active":  &stats.Active,
+		"dead":    &stats.Dead,
+	}
+	for kind, counter := range counters {
+		count, err := q.cache.Do(ctx, "ZCARD", q.key(kind)).Int()
+		if err != nil {
+			return stats, err
+		}
+		*counter = count
+	}
+	return stats, nil
+}
+
+func (q *Queue) DeadJobs(ctx context.Context, offset, limit int) ([]Job, error) {
+	if limit <= 0 {
+		return []Job{}, nil
+	}
+
+	ids, err := q.cache.Do(ctx, "ZREVRANGE", q.key("dead"), offset, offset+limit-1).Strings()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(ids))
+	for _, id := range ids {
+		job, err := q.Get(ctx, id)
+		if err == ErrJobNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+// RetryDead move job from dead letter queue back to ready queue with fresh attempts
+func (q *Queue) RetryDead(ctx context.Context, id string) error {
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	removed, err := q.cache.Do(ctx, "ZREM", q.key("dead"), id).Int()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrJobNotFound
+	}
+
+	job.Attempts = 0
+	job.LastError = ""
+	job.FailedAt = nil
+	if err = q.save(ctx, job); err != nil {
+		return err
+	}
+	return q.cache.Do(ctx, "ZADD", q.key("ready"), readyScore(*job), id).Error()
+}
+
+func (q *Queue) DeleteDead(ctx context.Context, id string) error {
+	removed, err := q.cache.Do(ctx, "ZREM", q.key("dead"), id).Int()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrJobNotFound
+	}
+	return q.cache.Del(ctx, q.jobKey(id)).Error()
+}
+
+func (q *Queue) save(ctx context.Context, job *Job) error {
+	jobValue, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.cache.SetNoExpire(ctx, q.jobKey(job.ID), jobValue).Error()
+}
+
+// enqueueScript save job and add it to queue in one step, so payload is not left behind when ZADD fail
+const enqueueScript = `
+redis.call('SET', KEYS[1], ARGV[1])
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[3])
+return 1
+`
+
+// dequeueScript promote due delayed jobs to ready queue,
+// then pop the highest priority job and mark it as active until lease expired with lease token ARGV[3].
+// KEYS[4] is prefix of job keys, it is passed as key so cache KeyPrefix is applied to it too
+const dequeueScript = `
+local due = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(due) do
+	redis.call('ZREM', KEYS[2], id)
+	local raw = redis.call('GET', KEYS[4] .. id)
+	if raw then
+		local job = cjson.decode(raw)
+		local priority = job['priority'] or 0
+		redis.call('ZADD', KEYS[1], string.format('%.0f', -priority * 1e13 + tonumber(ARGV[1])), id)
+	end
+end
+local ids = redis.call('ZRANGE', KEYS[1], 0, 0)
+if #ids == 0 then
+	return false
+end
+redis.call('ZREM', KEYS[1], ids[1])
+redis.call('ZADD', KEYS[3], ARGV[2], ids[1])
+redis.call('HSET', KEYS[5], ids[1], ARGV[3])
+return redis.call('GET', KEYS[4] .. ids[1])
+`
+
+// requeueExpiredScript move active jobs whose lease has expired (worker died) to delayed queue due at ARGV[2],
+// so the reaper can count the attempt before they are promoted to ready queue again
+const requeueExpiredScript = `
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('HDEL', KEYS[3], id)
+	redis.call('ZADD', KEYS[2], ARGV[2], id)
+end
+return expired
+`
+
+// completeScript delete job leased with token ARGV[2], 0 is returned when lease was lost
+const completeScript = `
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('DEL', KEYS[3])
+return 1
+`
+
+// failScript save job leased with token ARGV[2] and move it from active to KEYS[4] queue,
+// 0 is returned when lease was lost
+const failScript = `
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('SET', KEYS[3], ARGV[3])
+redis.call('ZADD', KEYS[4], ARGV[4], ARGV[1])
+redis.call('ZREM', KEYS[1], ARGV[1])
+return 1
+`
+
+func (q *Queue) dequeue(ctx context.Context, lease time.Duration) (*Job, error) {
+	token, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reply := q.cache.Do(ctx, "EVAL", dequeueScript, 5,
+		q.key("ready"), q.key("delayed"), q.key("active"), q.key("job:"), q.key("lease"),
+		toMillis(now), toMillis(now.Add(lease)), token)
+
+	var job Job
+	err = reply.Unmarshal(&job)
+	if err == cache.ErrorNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.lease = token
+	return &job, nil
+}
+
+// requeueExpired count attempt of jobs whose lease has expired and retry them after backoff or move them to dead
+// letter queue. job is parked in delayed queue for one lease while it is updated, so it still run again when this fail
+func (q *Queue) requeueExpired(ctx context.Context, lease time.Duration, backoff func(attempts int) time.Duration) (int, error) {
+	now := time.Now()
+	ids, err := q.cache.Do(ctx, "EVAL", requeueExpiredScript, 3,
+		q.key("active"), q.key("delayed"), q.key("lease"), toMillis(now), toMillis(now.Add(lease))).Strings()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, id := range ids {
+		job, err := q.Get(ctx, id)
+		if err == ErrJobNotFound {
+			if err = q.cache.Do(ctx, "ZREM", q.key("delayed"), id).Error(); err != nil {
+				return i, err
+			}
+			continue
+		}
+		if err != nil {
+			return i, err
+		}
+
+		job.Attempts++
+		job.LastError = errLeaseExpired.Error()
+		if err = q.reschedule(ctx, job, backoff(job.Attempts), "delayed"); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// complete delete job, errLeaseLost is returned when job was reaped and may run on another worker
+func (q *Queue) complete(ctx context.Context, job *Job) error {
+	owned, err := q.cache.Do(ctx, "EVAL", completeScript, 3,
+		q.key("active"), q.key("lease"), q.jobKey(job.ID), job.ID, job.lease).Int()
+	if err != nil {
+		return err
+	}
+	if owned == 0 {
+		return errLeaseLost
+	}
+	return nil
+}
+
+// fail record failed attempt and reschedule job, errLeaseLost is returned when job was reaped and may run on
+// another worker
+func (q *Queue) fail(ctx context.Context, job *Job, jobErr error, backoff time.Duration) error {
+	job.Attempts++
+	job.LastError = jobErr.Error()
+	to, score := q.nextQueue(job, backoff)
+
+	jobValue, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	owned, err := q.cache.Do(ctx, "EVAL", failScript, 4,
+		q.key("active"), q.key("lease"), q.jobKey(job.ID), q.key(to), job.ID, job.lease, jobValue, score).Int()
+	if err != nil {
+		return err
+	}
+	if owned == 0 {
+		return errLeaseLost
+	}
+	return nil
+}
+
+// nextQueue return delayed queue due after backoff, or dead letter queue once attempts exceed max retry
+func (q *Queue) nextQueue(job *Job, backoff time.Duration) (string, int64) {
+	now := time.Now()
+	if job.Attempts > job.MaxRetry {
+		job.FailedAt = &now
+		return "dead", toMillis(now)
+	}
+	return "delayed", toMillis(now.Add(backoff))
+}
+
+// reschedule move job from queue kind to delayed queue due after backoff, or to dead letter queue once attempts
+// exceed max retry
+func (q *Queue) reschedule(ctx context.Context, job *Job, backoff time.Duration, from string) error {
+	to, score := q.nextQueue(job, backoff)
+	if err := q.save(ctx, job); err != nil {
+		return err
+	}
+	if err := q.cache.Do(ctx, "ZADD", q.key(to), score, job.ID).Error(); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	return q.cache.Do(ctx, "ZREM", q.key(from), job.ID).Error()
+}
+
+func readyScore(job Job) float64 {
+	return -float64(job.Priority)*priorityWeight + float64(toMillis(job.EnqueuedAt))
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/vincentwijaya/go-pkg/v1/cache"
+)
+
+type testPayload struct {
+	Name string `json:"name"`
+}
+
+func TestWorkerWithKeyPrefix(t *testing.T) {
+	server := miniredis.RunT(t)
+	c, err := cache.ConnectRedis(cache.RedisConfig{Connection: server.Addr(), Timeout: 5, KeyPrefix: "svc:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	queue := NewQueue(c, QueueConfig{Name: "mail"})
+	if _, err = queue.Enqueue(ctx, "send", testPayload{Name: "now"}, EnqueueOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = queue.Enqueue(ctx, "send", testPayload{Name: "later"}, EnqueueOptions{Delay: 50 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range server.Keys() {
+		if !strings.HasPrefix(key, "svc:") {
+			t.Fatalf("key %s is stored without KeyPrefix", key)
+		}
+	}
+
+	processed := make(chan string, 2)
+	worker := NewWorker(c, WorkerConfig{Queue: "mail", PollInterval: 10 * time.Millisecond})
+	worker.Register("send", func(ctx context.Context, job *Job) error {
+		var payload testPayload
+		if err := job.Bind(&payload); err != nil {
+			return err
+		}
+		processed <- payload.Name
+		return nil
+	})
+	if err = worker.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer worker.Stop()
+
+	for _, want := range []string{"now", "later"} {
+		select {
+		case name := <-processed:
+			if name != want {
+				t.Fatalf("processed %s, want %s", name, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("job %s was not processed", want)
+		}
+	}
+	worker.Stop()
+
+	stats, err := queue.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{}) {
+		t.Fatalf("queue is not empty after processing: %+v", stats)
+	}
+}
+
+func TestCompleteAfterLeaseLost(t *testing.T) {
+	server := miniredis.RunT(t)
+	c, err := cache.ConnectRedis(cache.RedisConfig{Connection: server.Addr(), Timeout: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	queue := newQueue(c, QueueConfig{})
+	if _, err = queue.Enqueue(ctx, "send", testPayload{Name: "slow"}, EnqueueOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	slow, err := queue.dequeue(ctx, time.Millisecond)
+	if err != nil || slow == nil {
+		t.Fatalf("dequeue returned %v, %v", slow, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	noBackoff := func(int) time.Duration { return 0 }
+	if _, err = queue.requeueExpired(ctx, time.Millisecond, noBackoff); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	fast, err := queue.dequeue(ctx, time.Minute)
+	if err != nil || fast == nil || fast.ID != slow.ID {
+		t.Fatalf("dequeue returned %v, %v, want job %s", fast, err, slow.ID)
+	}
+
+	if err = queue.complete(ctx, slow); err != errLeaseLost {
+		t.Fatalf("complete of reaped job returned %v, want %v", err, errLeaseLost)
+	}
+	if err = queue.fail(ctx, slow, errLeaseExpired, 0); err != errLeaseLost {
+		t.Fatalf("fail of reaped job returned %v, want %v", err, errLeaseLost)
+	}
+	if err = queue.complete(ctx, fast); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := queue.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{}) {
+		t.Fatalf("queue is not empty after completing job: %+v", stats)
+	}
+}
@@ -0,0 +1,229 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/vincentwijaya/go-pkg/v1/cache"
+	"github.com/vincentwijaya/go-pkg/v1/log"
+)
+
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+type IWorker interface {
+	Register(jobType string, handler HandlerFunc)
+	Start(ctx context.Context) error
+	Stop()
+}
+
+type WorkerConfig struct {
+	// queue name to consume, must match the name used to enqueue
+	Queue string
+
+	// number of jobs processed concurrently, by default 1
+	Concurrency int
+
+	// interval to poll redis when the queue is empty, by default 1 second
+	PollInterval time.Duration
+
+	// maximum duration of a job before it is considered dead and requeued, by default 5 minutes
+	// handler context is cancelled a bit earlier (a fifth of lease, up to 10 seconds) so the result can be saved
+	Lease time.Duration
+
+	// retry backoff is RetryBackoff * 2^(attempts-1), capped at MaxRetryBackoff
+	// by default 5 seconds and 1 hour
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+type Worker struct {
+	queue    *Queue
+	config   WorkerConfig
+	handlers map[string]HandlerFunc
+	mu       sync.RWMutex
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+var ErrWorkerStarted = errors.New("worker already started")
+
+// maxFinishTimeout upper bound of time reserved from lease to save job result
+const maxFinishTimeout = 10 * time.Second
+
+// NewWorker create worker which process jobs from queue with registered handlers
+func NewWorker(c cache.ICache, config WorkerConfig) IWorker {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = 5 * time.Minute
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 5 * time.Second
+	}
+	if config.MaxRetryBackoff <= 0 {
+		config.MaxRetryBackoff = time.Hour
+	}
+
+	return &Worker{
+		queue:    newQueue(c, QueueConfig{Name: config.Queue}),
+		config:   config,
+		handlers: map[string]HandlerFunc{},
+	}
+}
+
+func (w *Worker) Register(jobType string, handler HandlerFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[jobType] = handler
+}
+
+// Start run worker processes in background until ctx is cancelled or Stop is called
+func (w *Worker) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return ErrWorkerStarted
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	for i := 0; i < w.config.Concurrency; i++ {
+		w.wg.Add(1)
+		go w.process(ctx)
+	}
+
+	w.wg.Add(1)
+	go w.reap(ctx)
+
+	return nil
+}
+
+// Stop stop fetching new jobs and wait for running jobs to finish
+func (w *Worker) Stop() {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	w.wg.Wait()
+
+	w.mu.Lock()
+	w.cancel = nil
+	w.mu.Unlock()
+}
+
+func (w *Worker) process(ctx context.Context) {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		job, err := w.queue.dequeue(ctx, w.config.Lease)
+		if err != nil {
+			log.WithField("queue", w.queue.name).Errorf("Failed to dequeue job. Error: %s", err)
+		}
+		if job == nil {
+			w.wait(ctx)
+			continue
+		}
+
+		w.run(job)
+	}
+}
+
+// run execute job handler, job is not bound to worker ctx so it can finish on Stop. handler must return before
+// lease expire and result is saved with its own timeout, so job is not reaped and run again while being saved
+func (w *Worker) run(job *Job) {
+	finishTimeout := w.config.Lease / 5
+	if finishTimeout > maxFinishTimeout {
+		finishTimeout = maxFinishTimeout
+	}
+
+	handleCtx, cancelHandle := context.WithTimeout(context.Background(), w.config.Lease-finishTimeout)
+	err := w.handle(handleCtx, job)
+	cancelHandle()
+
+	ctx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancel()
+
+	logger := log.WithFields(log.Fields{"queue": w.queue.name, "job_id": job.ID, "job_type": job.Type})
+	if err == nil {
+		if err = w.queue.complete(ctx, job); err != nil {
+			logger.Errorf("Failed to complete job. Error: %s", err)
+		}
+		return
+	}
+
+	logger.Errorf("Failed to process job (attempt %d). Error: %s", job.Attempts+1, err)
+	if err = w.queue.fail(ctx, job, err, w.backoff(job.Attempts+1)); err != nil {
+		logger.Errorf("Failed to reschedule job. Error: %s", err)
+	}
+}
+
+func (w *Worker) handle(ctx context.Context, job *Job) (err error) {
+	w.mu.RLock()
+	handler, ok := w.handlers[job.Type]
+	w.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no handler registered for job type %s", job.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+func (w *Worker) backoff(attempts int) time.Duration {
+	backoff := float64(w.config.RetryBackoff) * math.Pow(2, float64(attempts-1))
+	if backoff > float64(w.config.MaxRetryBackoff) {
+		return w.config.MaxRetryBackoff
+	}
+	return time.Duration(backoff)
+}
+
+// reap requeue jobs whose worker died before finishing them, lease expiry count as failed attempt
+func (w *Worker) reap(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.config.Lease / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.queue.requeueExpired(ctx, w.config.Lease, w.backoff); err != nil {
+				log.WithField("queue", w.queue.name).Errorf("Failed to requeue expired job. Error: %s", err)
+			}
+		}
+	}
+}
+
+func (w *Worker) wait(ctx context.Context) {
+	timer := time.NewTimer(w.config.PollInterval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,374 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+type Client interface {
+	// Authenticate verify username and password with bind, and map the user entry into dest
+	Authenticate(ctx context.Context, username, password string, dest interface{}) error
+	FindUser(ctx context.Context, username string, dest interface{}) error
+	UserGroups(ctx context.Context, userDN string, dest interface{}) error
+	// Search run paged search and map entries into dest, dest must be pointer to slice of struct
+	Search(ctx context.Context, request SearchRequest, dest interface{}) error
+	Close()
+}
+
+type Config struct {
+	// server url, eg: ldaps://ad.corp.local:636 or ldap://ad.corp.local:389
+	URL string
+
+	// upgrade ldap:// connection with StartTLS
+	StartTLS bool
+
+	// CA certificate file to verify server certificate, system pool is used when empty
+	RootCAFile         string
+	InsecureSkipVerify bool
+
+	// service account used for searching
+	BindDN       string
+	BindPassword string
+
+	// eg: DC=corp,DC=local
+	BaseDN string
+
+	// filter to find user, %s is replaced by escaped username
+	// by default (&(objectClass=user)(sAMAccountName=%s))
+	UserFilter string
+
+	// filter to find user groups, %s is replaced by escaped user DN
+	// by default (&(objectClass=group)(member=%s))
+	GroupFilter string
+
+	// network timeout (in second), by default 10 seconds
+	Timeout int
+
+	// maximum open connections in pool, by default 4
+	MaxConns int
+
+	// page size for paged search, by default 500
+	PageSize int
+}
+
+type SearchRequest struct {
+	// search base, Config.BaseDN is used when empty
+	BaseDN string
+	Filter string
+
+	// attributes to fetch, fetched from dest struct tags when empty
+	Attributes []string
+
+	// maximum entries returned, zero means no limit
+	SizeLimit int
+}
+
+type LDAP struct {
+	config    Config
+	tlsConfig *tls.Config
+	pool      chan *goldap.Conn
+	slots     chan struct{}
+
+	// mu guard closing done against put so no connection is returned to pool after Close drained it
+	mu   sync.Mutex
+	done chan struct{}
+}
+
+const ErrorFailedConnect = "Failed to connect to ldap %s. Error: %s"
+
+var (
+	ErrClosed             = errors.New("ldap client is closed")
+	ErrUserNotFound       = errors.New("ldap user not found")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+)
+
+// Connect create ldap client with connection pool bound as service account
+func Connect(config Config) (Client, error) {
+	if config.UserFilter == "" {
+		config.UserFilter = "(&(objectClass=user)(sAMAccountName=%s))"
+	}
+	if config.GroupFilter == "" {
+		config.GroupFilter = "(&(objectClass=group)(member=%s))"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10
+	}
+	if config.MaxConns <= 0 {
+		config.MaxConns = 4
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = 500
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &LDAP{
+		config:    config,
+		tlsConfig: tlsConfig,
+		pool:      make(chan *goldap.Conn, config.MaxConns),
+		slots:     make(chan struct{}, config.MaxConns),
+		done:      make(chan struct{}),
+	}
+
+	conn, err := client.get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	client.put(conn)
+
+	return client, nil
+}
+
+func newTLSConfig(config Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	if config.RootCAFile != "" {
+		ca, err := ioutil.ReadFile(config.RootCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("Failed to parse CA certificate %s", config.RootCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// dial open new connection, bound as bindDN when it is not empty
+func (l *LDAP) dial(bindDN, password string) (*goldap.Conn, error) {
+	timeout := time.Duration(l.config.Timeout) * time.Second
+	conn, err := goldap.DialURL(l.config.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		goldap.DialWithTLSConfig(l.tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf(ErrorFailedConnect, l.config.URL, err)
+	}
+	conn.SetTimeout(timeout)
+
+	if l.config.StartTLS {
+		if err = conn.StartTLS(l.tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf(ErrorFailedConnect, l.config.URL, err)
+		}
+	}
+
+	if bindDN != "" {
+		if err = conn.Bind(bindDN, password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (l *LDAP) get(ctx context.Context) (*goldap.Conn, error) {
+	for {
+		select {
+		case <-l.done:
+			return nil, ErrClosed
+		default:
+		}
+
+		select {
+		case conn := <-l.pool:
+			if conn.IsClosing() {
+				l.discard(conn)
+				continue
+			}
+			return conn, nil
+		default:
+		}
+
+		select {
+		case <-l.done:
+			return nil, ErrClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case conn := <-l.pool:
+			if conn.IsClosing() {
+				l.discard(conn)
+				continue
+			}
+			return conn, nil
+		case l.slots <- struct{}{}:
+			conn, err := l.dial(l.config.BindDN, l.config.BindPassword)
+			if err != nil {
+				<-l.slots
+				return nil, err
+			}
+			return conn, nil
+		}
+	}
+}
+
+// put return healthy connection to pool, send never block as pool is as large as slots
+func (l *LDAP) put(conn *goldap.Conn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-l.done:
+		l.discard(conn)
+		return
+	default:
+	}
+	l.pool <- conn
+}
+
+func (l *LDAP) discard(conn *goldap.Conn) {
+	conn.Close()
+	<-l.slots
+}
+
+// do run fn with pooled connection, connection is discarded on network error
+func (l *LDAP) do(ctx context.Context, fn func(conn *goldap.Conn) error) error {
+	conn, err := l.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(conn)
+	if err != nil && goldap.IsErrorWithCode(err, goldap.ErrorNetwork) {
+		l.discard(conn)
+		return err
+	}
+	l.put(conn)
+	return err
+}
+
+func (l *LDAP) Authenticate(ctx context.Context, username, password string, dest interface{}) error {
+	// empty password is an unauthenticated bind which always succeed
+	if password == "" {
+		return ErrInvalidCredentials
+	}
+
+	entry, err := l.findUser(ctx, username, dest)
+	if err == ErrUserNotFound {
+		return ErrInvalidCredentials
+	}
+	if err != nil {
+		return err
+	}
+
+	// bind on a dedicated connection so pooled connections keep the service account identity
+	conn, err := l.dial(entry.DN, password)
+	if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+		return ErrInvalidCredentials
+	}
+	if err != nil {
+		return err
+	}
+	conn.Close()
+
+	if dest == nil {
+		return nil
+	}
+	return mapEntry(entry, dest)
+}
+
+func (l *LDAP) FindUser(ctx context.Context, username string, dest interface{}) error {
+	entry, err := l.findUser(ctx, username, dest)
+	if err != nil {
+		return err
+	}
+	return mapEntry(entry, dest)
+}
+
+func (l *LDAP) findUser(ctx context.Context, username string, dest interface{}) (*goldap.Entry, error) {
+	attributes, err := structAttributes(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	request := goldap.NewSearchRequest(
+		l.config.BaseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 2, l.config.Timeout, false,
+		fmt.Sprintf(l.config.UserFilter, goldap.EscapeFilter(username)),
+		attributes, nil)
+
+	var result *goldap.SearchResult
+	err = l.do(ctx, func(conn *goldap.Conn) error {
+		result, err = conn.Search(request)
+		return err
+	})
+	if err != nil && !goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+		return nil, err
+	}
+	if result == nil || len(result.Entries) == 0 {
+		return nil, ErrUserNotFound
+	}
+	if len(result.Entries) > 1 {
+		return nil, fmt.Errorf("Username %s matches more than one ldap entry", username)
+	}
+	return result.Entries[0], nil
+}
+
+func (l *LDAP) UserGroups(ctx context.Context, userDN string, dest interface{}) error {
+	return l.Search(ctx, SearchRequest{
+		Filter: fmt.Sprintf(l.config.GroupFilter, goldap.EscapeFilter(userDN)),
+	}, dest)
+}
+
+func (l *LDAP) Search(ctx context.Context, request SearchRequest, dest interface{}) error {
+	attributes := request.Attributes
+	if len(attributes) == 0 {
+		var err error
+		attributes, err = sliceAttributes(dest)
+		if err != nil {
+			return err
+		}
+	}
+
+	baseDN := request.BaseDN
+	if baseDN == "" {
+		baseDN = l.config.BaseDN
+	}
+
+	searchRequest := goldap.NewSearchRequest(
+		baseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, request.SizeLimit, l.config.Timeout, false,
+		request.Filter, attributes, nil)
+
+	var result *goldap.SearchResult
+	err := l.do(ctx, func(conn *goldap.Conn) error {
+		var err error
+		result, err = conn.SearchWithPaging(searchRequest, uint32(l.config.PageSize))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return mapEntries(result.Entries, dest)
+}
+
+// Close close all idle connections, connections in use are closed when returned
+func (l *LDAP) Close() {
+	l.mu.Lock()
+	select {
+	case <-l.done:
+		l.mu.Unlock()
+		return
+	default:
+	}
+	close(l.done)
+	l.mu.Unlock()
+
+	for {
+		select {
+		case conn := <-l.pool:
+			l.discard(conn)
+		default:
+			return
+		}
+	}
+}
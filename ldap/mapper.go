@@ -0,0 +1,161 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// tagName struct tag to map ldap attribute into field, eg:
+//
+//	type User struct {
+//		DN          string   `ldap:"dn"`
+//		Username    string   `ldap:"sAMAccountName"`
+//		DisplayName string   `ldap:"displayName"`
+//		MemberOf    []string `ldap:"memberOf"`
+//	}
+const tagName = "ldap"
+
+var errInvalidDest = errors.New("Destination must be pointer to struct")
+
+var errInvalidSliceDest = errors.New("Destination must be pointer to slice of struct")
+
+// structAttributes return attribute names from dest struct tags, nil dest fetch no attribute
+func structAttributes(dest interface{}) ([]string, error) {
+	if dest == nil {
+		return []string{"dn"}, nil
+	}
+	t := reflect.TypeOf(dest)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, errInvalidDest
+	}
+	return attributes(t.Elem()), nil
+}
+
+func sliceAttributes(dest interface{}) ([]string, error) {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice || t.Elem().Elem().Kind() != reflect.Struct {
+		return nil, errInvalidSliceDest
+	}
+	return attributes(t.Elem().Elem()), nil
+}
+
+func attributes(t reflect.Type) []string {
+	var attrs []string
+	for i := 0; i < t.NumField(); i++ {
+		name := fieldAttribute(t.Field(i))
+		if name == "" || strings.EqualFold(name, "dn") {
+			continue
+		}
+		attrs = append(attrs, name)
+	}
+	if len(attrs) == 0 {
+		attrs = []string{"dn"}
+	}
+	return attrs
+}
+
+func fieldAttribute(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	name := field.Tag.Get(tagName)
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func mapEntries(entries []*goldap.Entry, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice || v.Elem().Type().Elem().Kind() != reflect.Struct {
+		return errInvalidSliceDest
+	}
+
+	slice := v.Elem()
+	slice.Set(reflect.MakeSlice(slice.Type(), 0, len(entries)))
+	for _, entry := range entries {
+		item := reflect.New(slice.Type().Elem())
+		if err := mapEntry(entry, item.Interface()); err != nil {
+			return err
+		}
+		slice.Set(reflect.Append(slice, item.Elem()))
+	}
+	return nil
+}
+
+func mapEntry(entry *goldap.Entry, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errInvalidDest
+	}
+
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := fieldAttribute(t.Field(i))
+		if name == "" {
+			continue
+		}
+
+		var values []string
+		var raw []byte
+		if strings.EqualFold(name, "dn") {
+			values = []string{entry.DN}
+			raw = []byte(entry.DN)
+		} else {
+			values = entry.GetEqualFoldAttributeValues(name)
+			raw = entry.GetEqualFoldRawAttributeValue(name)
+		}
+
+		if err := setField(v.Field(i), values, raw); err != nil {
+			return fmt.Errorf("Failed to map ldap attribute %s. Error: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, values []string, raw []byte) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(values[0])
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.ToLower(values[0]))
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(values[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Slice:
+		switch field.Type().Elem().Kind() {
+		case reflect.Uint8:
+			field.SetBytes(raw)
+		case reflect.String:
+			field.Set(reflect.ValueOf(append([]string{}, values...)).Convert(field.Type()))
+		default:
+			return fmt.Errorf("unsupported field type %s", field.Type())
+		}
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
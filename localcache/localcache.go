@@ -0,0 +1,344 @@
+package localcache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Cache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Set(key K, value V)
+	SetWithTTL(key K, value V, ttl time.Duration)
+	Delete(key K)
+	// GetOrLoad return cached value or call loader once for concurrent callers of the same key
+	GetOrLoad(ctx context.Context, key K, loader LoaderFunc[K, V]) (V, error)
+	Len() int
+	Purge()
+	Stats() Stats
+	Close()
+}
+
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+type Policy string
+
+const (
+	LRU Policy = "lru"
+	LFU Policy = "lfu"
+)
+
+type Config struct {
+	// number of shards, rounded up to power of two, by default 16
+	Shards int
+
+	// maximum number of entries, zero means unlimited
+	MaxSize int
+
+	// eviction policy when MaxSize is reached, by default LRU
+	Eviction Policy
+
+	// ttl used by Set and GetOrLoad, zero means never expired
+	DefaultTTL time.Duration
+
+	// interval of expired entries cleanup, by default 1 minute
+	// negative value disable the janitor, expired entries are still removed on access
+	CleanupInterval time.Duration
+}
+
+type Stats struct {
+	Hits       uint64
+	Misses     uint64
+	Evictions  uint64
+	Expired    uint64
+	Loads      uint64
+	LoadErrors uint64
+}
+
+type LocalCache[K comparable, V any] struct {
+	// stats is accessed atomically, keep it first for 64-bit alignment
+	stats      Stats
+	shards     []*shard[K, V]
+	mask       uint64
+	defaultTTL time.Duration
+	group      group[K, V]
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+type shard[K comparable, V any] struct {
+	mu      sync.Mutex
+	items   map[K]*entry[K, V]
+	policy  policy[K, V]
+	maxSize int
+}
+
+type entry[K comparable, V any] struct {
+	key      K
+	value    V
+	expireAt int64
+	// bookkeeping of eviction policy
+	element *list.Element
+	freq    uint64
+	tick    uint64
+	index   int
+}
+
+// New create sharded in-memory cache
+func New[K comparable, V any](config Config) Cache[K, V] {
+	shards := 16
+	if config.Shards > 0 {
+		shards = 1
+		for shards < config.Shards {
+			shards <<= 1
+		}
+	}
+
+	maxSize := 0
+	if config.MaxSize > 0 {
+		maxSize = (config.MaxSize + shards - 1) / shards
+	}
+
+	c := &LocalCache[K, V]{
+		shards:     make([]*shard[K, V], shards),
+		mask:       uint64(shards - 1),
+		defaultTTL: config.DefaultTTL,
+		done:       make(chan struct{}),
+	}
+	for i := range c.shards {
+		c.shards[i] = &shard[K, V]{
+			items:   map[K]*entry[K, V]{},
+			policy:  newPolicy[K, V](config.Eviction),
+			maxSize: maxSize,
+		}
+	}
+
+	interval := config.CleanupInterval
+	if interval == 0 {
+		interval = time.Minute
+	}
+	if interval > 0 {
+		go c.janitor(interval)
+	}
+
+	return c
+}
+
+func (c *LocalCache[K, V]) shard(key K) *shard[K, V] {
+	return c.shards[hashKey(key)&c.mask]
+}
+
+func hashKey[K comparable](key K) uint64 {
+	switch k := interface{}(key).(type) {
+	case string:
+		h := fnv.New64a()
+		h.Write([]byte(k))
+		return h.Sum64()
+	case int:
+		return mix(uint64(k))
+	case int64:
+		return mix(uint64(k))
+	case int32:
+		return mix(uint64(k))
+	case uint:
+		return mix(uint64(k))
+	case uint64:
+		return mix(k)
+	case uint32:
+		return mix(uint64(k))
+	default:
+		h := fnv.New64a()
+		fmt.Fprint(h, k)
+		return h.Sum64()
+	}
+}
+
+// mix spread sequential integer keys across shards (splitmix64 finalizer)
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func (e *entry[K, V]) expired(now int64) bool {
+	return e.expireAt > 0 && now > e.expireAt
+}
+
+func (c *LocalCache[K, V]) Get(key K) (V, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	e, ok := s.items[key]
+	if ok && e.expired(time.Now().UnixNano()) {
+		s.remove(e)
+		atomic.AddUint64(&c.stats.Expired, 1)
+		ok = false
+	}
+	if !ok {
+		s.mu.Unlock()
+		atomic.AddUint64(&c.stats.Misses, 1)
+		var zero V
+		return zero, false
+	}
+	s.policy.access(e)
+	value := e.value
+	s.mu.Unlock()
+
+	atomic.AddUint64(&c.stats.Hits, 1)
+	return value, true
+}
+
+func (c *LocalCache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.defaultTTL)
+}
+
+// SetWithTTL store value with ttl, zero ttl means never expired
+func (c *LocalCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	var expireAt int64
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl).UnixNano()
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.items[key]; ok {
+		e.value = value
+		e.expireAt = expireAt
+		s.policy.access(e)
+		return
+	}
+
+	if s.maxSize > 0 && len(s.items) >= s.maxSize {
+		if victim := s.policy.victim(); victim != nil {
+			s.remove(victim)
+			atomic.AddUint64(&c.stats.Evictions, 1)
+		}
+	}
+
+	e := &entry[K, V]{key: key, value: value, expireAt: expireAt}
+	s.items[key] = e
+	s.policy.add(e)
+}
+
+func (c *LocalCache[K, V]) Delete(key K) {
+	s := c.shard(key)
+	s.mu.Lock()
+	if e, ok := s.items[key]; ok {
+		s.remove(e)
+	}
+	s.mu.Unlock()
+}
+
+func (c *LocalCache[K, V]) GetOrLoad(ctx context.Context, key K, loader LoaderFunc[K, V]) (V, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	return c.group.do(key, func() (V, error) {
+		// another caller may have loaded the key while we were waiting
+		if value, ok := c.peek(key); ok {
+			return value, nil
+		}
+
+		atomic.AddUint64(&c.stats.Loads, 1)
+		value, err := loader(ctx, key)
+		if err != nil {
+			atomic.AddUint64(&c.stats.LoadErrors, 1)
+			return value, err
+		}
+		c.Set(key, value)
+		return value, nil
+	})
+}
+
+// peek get value without touching stats and eviction policy
+func (c *LocalCache[K, V]) peek(key K) (V, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[key]
+	if !ok || e.expired(time.Now().UnixNano()) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (c *LocalCache[K, V]) Len() int {
+	total := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		total += len(s.items)
+		s.mu.Unlock()
+	}
+	return total
+}
+
+func (c *LocalCache[K, V]) Purge() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for _, e := range s.items {
+			s.remove(e)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (c *LocalCache[K, V]) Stats() Stats {
+	return Stats{
+		Hits:       atomic.LoadUint64(&c.stats.Hits),
+		Misses:     atomic.LoadUint64(&c.stats.Misses),
+		Evictions:  atomic.LoadUint64(&c.stats.Evictions),
+		Expired:    atomic.LoadUint64(&c.stats.Expired),
+		Loads:      atomic.LoadUint64(&c.stats.Loads),
+		LoadErrors: atomic.LoadUint64(&c.stats.LoadErrors),
+	}
+}
+
+// Close stop the expiration janitor
+func (c *LocalCache[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *LocalCache[K, V]) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+func (c *LocalCache[K, V]) deleteExpired() {
+	now := time.Now().UnixNano()
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for _, e := range s.items {
+			if e.expired(now) {
+				s.remove(e)
+				atomic.AddUint64(&c.stats.Expired, 1)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *shard[K, V]) remove(e *entry[K, V]) {
+	delete(s.items, e.key)
+	s.policy.remove(e)
+}
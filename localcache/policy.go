@@ -0,0 +1,109 @@
+package localcache
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// policy keep track of entries order for eviction, called with shard lock held
+type policy[K comparable, V any] interface {
+	add(e *entry[K, V])
+	access(e *entry[K, V])
+	remove(e *entry[K, V])
+	victim() *entry[K, V]
+}
+
+func newPolicy[K comparable, V any](p Policy) policy[K, V] {
+	if p == LFU {
+		return &lfu[K, V]{}
+	}
+	return &lru[K, V]{list: list.New()}
+}
+
+// lru evict least recently used entry
+type lru[K comparable, V any] struct {
+	list *list.List
+}
+
+func (p *lru[K, V]) add(e *entry[K, V]) {
+	e.element = p.list.PushFront(e)
+}
+
+func (p *lru[K, V]) access(e *entry[K, V]) {
+	p.list.MoveToFront(e.element)
+}
+
+func (p *lru[K, V]) remove(e *entry[K, V]) {
+	p.list.Remove(e.element)
+}
+
+func (p *lru[K, V]) victim() *entry[K, V] {
+	back := p.list.Back()
+	if back == nil {
+		return nil
+	}
+	return back.Value.(*entry[K, V])
+}
+
+// lfu evict least frequently used entry, older entry is evicted first on tie
+type lfu[K comparable, V any] struct {
+	entries []*entry[K, V]
+	tick    uint64
+}
+
+func (p *lfu[K, V]) add(e *entry[K, V]) {
+	p.tick++
+	e.freq = 1
+	e.tick = p.tick
+	heap.Push(p, e)
+}
+
+func (p *lfu[K, V]) access(e *entry[K, V]) {
+	p.tick++
+	e.freq++
+	e.tick = p.tick
+	heap.Fix(p, e.index)
+}
+
+func (p *lfu[K, V]) remove(e *entry[K, V]) {
+	heap.Remove(p, e.index)
+}
+
+func (p *lfu[K, V]) victim() *entry[K, V] {
+	if len(p.entries) == 0 {
+		return nil
+	}
+	return p.entries[0]
+}
+
+func (p *lfu[K, V]) Len() int {
+	return len(p.entries)
+}
+
+func (p *lfu[K, V]) Less(i, j int) bool {
+	if p.entries[i].freq == p.entries[j].freq {
+		return p.entries[i].tick < p.entries[j].tick
+	}
+	return p.entries[i].freq < p.entries[j].freq
+}
+
+func (p *lfu[K, V]) Swap(i, j int) {
+	p.entries[i], p.entries[j] = p.entries[j], p.entries[i]
+	p.entries[i].index = i
+	p.entries[j].index = j
+}
+
+func (p *lfu[K, V]) Push(x interface{}) {
+	e := x.(*entry[K, V])
+	e.index = len(p.entries)
+	p.entries = append(p.entries, e)
+}
+
+func (p *lfu[K, V]) Pop() interface{} {
+	last := len(p.entries) - 1
+	e := p.entries[last]
+	p.entries[last] = nil
+	p.entries = p.entries[:last]
+	e.index = -1
+	return e
+}
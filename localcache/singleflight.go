@@ -0,0 +1,68 @@
+package localcache
+
+import (
+	"fmt"
+	"sync"
+)
+
+// group deduplicate concurrent loads of the same key
+type group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+type call[V any] struct {
+	wg    sync.WaitGroup
+	value V
+	err   error
+}
+
+// panicError is returned to callers waiting on loader that panicked
+type panicError struct {
+	value interface{}
+}
+
+func (p *panicError) Error() string {
+	return fmt.Sprintf("Loader panicked: %v", p.value)
+}
+
+func (g *group[K, V]) do(key K, fn func() (V, error)) (V, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[K]*call[V]{}
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.value, c.err
+	}
+
+	c := &call[V]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	normalReturn := false
+	defer func() {
+		var recovered interface{}
+		if !normalReturn {
+			// waiters must not see zero value as successful load, runtime.Goexit recover nil
+			recovered = recover()
+			c.value = *new(V)
+			c.err = &panicError{value: recovered}
+		}
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+
+	c.value, c.err = fn()
+	normalReturn = true
+	return c.value, c.err
+}
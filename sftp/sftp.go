@@ -0,0 +1,463 @@
+package sftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	pkgsftp "github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+type Client interface {
+	Upload(ctx context.Context, remotePath string, src io.Reader) error
+	Download(ctx context.Context, remotePath string, dst io.Writer) error
+	List(ctx context.Context, dir string) ([]os.FileInfo, error)
+	Delete(ctx context.Context, remotePath string) error
+	Rename(ctx context.Context, oldPath, newPath string) error
+	Close() error
+}
+
+type Config struct {
+	// server address in host:port format, eg: sftp.bank.co.id:22
+	Address string
+	User    string
+
+	// password auth, used when set
+	Password string
+
+	// private key auth, PrivateKey takes precedence over PrivateKeyFile
+	PrivateKey     []byte
+	PrivateKeyFile string
+	Passphrase     string
+
+	// server public key in authorized_keys format, eg: ssh-rsa AAAA...
+	// required unless InsecureSkipHostKeyCheck is set
+	HostKey string
+
+	// accept any server key when HostKey is empty, connection is open to man-in-the-middle attack
+	// so only use it for local development
+	InsecureSkipHostKeyCheck bool
+
+	// dial timeout (in second), by default 30 seconds
+	Timeout int
+
+	// maximum open connections in pool, by default 2
+	MaxConns int
+
+	// keepalive interval (in second) for idle connections, by default 30 seconds
+	// negative value disable keepalive
+	KeepAlive int
+
+	// maximum retry on transient failures, by default 3
+	MaxRetry int
+}
+
+type SFTP struct {
+	config    Config
+	sshConfig *ssh.ClientConfig
+	pool      chan *connection
+	slots     chan struct{}
+
+	// mu guard closing done against put so no connection is returned to pool after Close drained it
+	mu   sync.Mutex
+	done chan struct{}
+}
+
+type connection struct {
+	ssh  *ssh.Client
+	sftp *pkgsftp.Client
+}
+
+const ErrorFailedConnect = "Failed to connect to sftp %s. Error: %s"
+
+var ErrClosed = errors.New("sftp client is closed")
+
+// Connect create sftp client with connection pool and verify the first connection
+func Connect(config Config) (Client, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = 30
+	}
+	if config.MaxConns <= 0 {
+		config.MaxConns = 2
+	}
+	if config.KeepAlive == 0 {
+		config.KeepAlive = 30
+	}
+	if config.MaxRetry <= 0 {
+		config.MaxRetry = 3
+	}
+
+	sshConfig, err := newSSHConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &SFTP{
+		config:    config,
+		sshConfig: sshConfig,
+		pool:      make(chan *connection, config.MaxConns),
+		slots:     make(chan struct{}, config.MaxConns),
+		done:      make(chan struct{}),
+	}
+
+	conn, err := client.get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	client.put(conn)
+
+	if config.KeepAlive > 0 {
+		go client.keepAlive(time.Duration(config.KeepAlive) * time.Second)
+	}
+
+	return client, nil
+}
+
+func newSSHConfig(config Config) (*ssh.ClientConfig, error) {
+	var auths []ssh.AuthMethod
+
+	key := config.PrivateKey
+	if len(key) == 0 && config.PrivateKeyFile != "" {
+		var err error
+		key, err = ioutil.ReadFile(config.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(key) > 0 {
+		var signer ssh.Signer
+		var err error
+		if config.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(config.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, ssh.PublicKeys(signer))
+	}
+
+	if config.Password != "" {
+		auths = append(auths, ssh.Password(config.Password))
+	}
+
+	if len(auths) == 0 {
+		return nil, errors.New("Missing password or private key for sftp authentication")
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case config.HostKey != "":
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.HostKey))
+		if err != nil {
+			return nil, err
+		}
+		hostKeyCallback = ssh.FixedHostKey(hostKey)
+	case config.InsecureSkipHostKeyCheck:
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	default:
+		return nil, errors.New("Missing host key for sftp server, set HostKey or InsecureSkipHostKeyCheck")
+	}
+
+	return &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            auths,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         time.Duration(config.Timeout) * time.Second,
+	}, nil
+}
+
+func (s *SFTP) dial(ctx context.Context) (*connection, error) {
+	dialer := net.Dialer{Timeout: s.sshConfig.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", s.config.Address)
+	if err != nil {
+		return nil, fmt.Errorf(ErrorFailedConnect, s.config.Address, err)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, s.config.Address, s.sshConfig)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf(ErrorFailedConnect, s.config.Address, err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+
+	sftpClient, err := pkgsftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf(ErrorFailedConnect, s.config.Address, err)
+	}
+
+	return &connection{ssh: sshClient, sftp: sftpClient}, nil
+}
+
+// get take idle connection from pool or dial a new one when pool is not full
+func (s *SFTP) get(ctx context.Context) (*connection, error) {
+	select {
+	case <-s.done:
+		return nil, ErrClosed
+	default:
+	}
+
+	select {
+	case conn := <-s.pool:
+		return conn, nil
+	default:
+	}
+
+	select {
+	case <-s.done:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case conn := <-s.pool:
+		return conn, nil
+	case s.slots <- struct{}{}:
+		conn, err := s.dial(ctx)
+		if err != nil {
+			<-s.slots
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+// put return healthy connection to pool, send never block as pool is as large as slots
+func (s *SFTP) put(conn *connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		s.discard(conn)
+		return
+	default:
+	}
+	s.pool <- conn
+}
+
+// discard close broken connection and free its pool slot
+func (s *SFTP) discard(conn *connection) {
+	conn.close()
+	<-s.slots
+}
+
+func (c *connection) close() {
+	c.sftp.Close()
+	c.ssh.Close()
+}
+
+func (s *SFTP) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		idle := len(s.pool)
+		for i := 0; i < idle; i++ {
+			var conn *connection
+			select {
+			case conn = <-s.pool:
+			default:
+			}
+			if conn == nil {
+				break
+			}
+
+			if _, _, err := conn.ssh.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				s.discard(conn)
+				continue
+			}
+			s.put(conn)
+		}
+	}
+}
+
+// do run fn with pooled connection, broken connection is discarded and fn is retried
+// while retryable return true
+func (s *SFTP) do(ctx context.Context, retryable func() bool, fn func(client *pkgsftp.Client) error) error {
+	var err error
+	for attempt := 0; attempt <= s.config.MaxRetry; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(time.Duration(1<<uint(attempt-1)) * time.Second)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		var conn *connection
+		conn, err = s.get(ctx)
+		if err != nil {
+			if err == ErrClosed || err == ctx.Err() {
+				return err
+			}
+			continue
+		}
+
+		err = fn(conn.sftp)
+		if err == nil || !isTransient(err) {
+			s.put(conn)
+			return err
+		}
+
+		s.discard(conn)
+		if !retryable() {
+			return err
+		}
+	}
+	return err
+}
+
+func isTransient(err error) bool {
+	if err == io.EOF || err == io.ErrUnexpectedEOF || err == pkgsftp.ErrSSHFxConnectionLost || err == pkgsftp.ErrSSHFxNoConnection {
+		return true
+	}
+	if statusErr, ok := err.(*pkgsftp.StatusError); ok {
+		return statusErr.FxCode() == pkgsftp.ErrSSHFxConnectionLost || statusErr.FxCode() == pkgsftp.ErrSSHFxNoConnection
+	}
+	_, ok := err.(net.Error)
+	return ok
+}
+
+func alwaysRetry() bool {
+	return true
+}
+
+// neverRetry is used by operation which is not idempotent, request may have reached server before connection is lost
+// so retry could fail on already applied change or apply it to another file
+func neverRetry() bool {
+	return false
+}
+
+// Upload stream src into remotePath, parent directory is created when missing
+// retry is only possible when src implements io.Seeker or nothing has been read yet
+func (s *SFTP) Upload(ctx context.Context, remotePath string, src io.Reader) error {
+	counter := &countingReader{reader: src}
+	seeker, seekable := src.(io.Seeker)
+	retryable := func() bool {
+		if seekable {
+			_, err := seeker.Seek(0, io.SeekStart)
+			counter.count = 0
+			return err == nil
+		}
+		return counter.count == 0
+	}
+
+	return s.do(ctx, retryable, func(client *pkgsftp.Client) error {
+		if err := client.MkdirAll(path.Dir(remotePath)); err != nil {
+			return err
+		}
+		file, err := client.Create(remotePath)
+		if err != nil {
+			return err
+		}
+		if _, err = file.ReadFrom(counter); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	})
+}
+
+// Download stream remotePath into dst
+// retry is only possible when nothing has been written to dst yet
+func (s *SFTP) Download(ctx context.Context, remotePath string, dst io.Writer) error {
+	counter := &countingWriter{writer: dst}
+	retryable := func() bool {
+		return counter.count == 0
+	}
+
+	return s.do(ctx, retryable, func(client *pkgsftp.Client) error {
+		file, err := client.Open(remotePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = file.WriteTo(counter)
+		return err
+	})
+}
+
+func (s *SFTP) List(ctx context.Context, dir string) ([]os.FileInfo, error) {
+	var files []os.FileInfo
+	err := s.do(ctx, alwaysRetry, func(client *pkgsftp.Client) error {
+		var err error
+		files, err = client.ReadDir(dir)
+		return err
+	})
+	return files, err
+}
+
+// Delete is not retried after connection is lost while deleting, check remotePath before calling it again
+func (s *SFTP) Delete(ctx context.Context, remotePath string) error {
+	return s.do(ctx, neverRetry, func(client *pkgsftp.Client) error {
+		return client.Remove(remotePath)
+	})
+}
+
+// Rename is not retried after connection is lost while renaming, check both paths before calling it again
+func (s *SFTP) Rename(ctx context.Context, oldPath, newPath string) error {
+	return s.do(ctx, neverRetry, func(client *pkgsftp.Client) error {
+		return client.Rename(oldPath, newPath)
+	})
+}
+
+// Close close all idle connections, connections in use are closed when returned
+func (s *SFTP) Close() error {
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		return nil
+	default:
+	}
+	close(s.done)
+	s.mu.Unlock()
+
+	for {
+		select {
+		case conn := <-s.pool:
+			s.discard(conn)
+		default:
+			return nil
+		}
+	}
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,120 @@
+package timeutil
+
+import "time"
+
+// BusinessCalendar know weekend and holidays to calculate business days
+type BusinessCalendar struct {
+	weekend  map[time.Weekday]bool
+	holidays map[string]bool
+}
+
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// NewBusinessCalendar create calendar with saturday and sunday as weekend
+func NewBusinessCalendar(holidays ...time.Time) *BusinessCalendar {
+	c := &BusinessCalendar{
+		weekend:  map[time.Weekday]bool{time.Saturday: true, time.Sunday: true},
+		holidays: map[string]bool{},
+	}
+	c.AddHolidays(holidays...)
+	return c
+}
+
+// SetWeekend replace weekend days
+func (c *BusinessCalendar) SetWeekend(days ...time.Weekday) {
+	c.weekend = map[time.Weekday]bool{}
+	for _, v := range days {
+		c.weekend[v] = true
+	}
+}
+
+// AddHolidays register holidays, only the date part is used
+func (c *BusinessCalendar) AddHolidays(holidays ...time.Time) {
+	for _, v := range holidays {
+		c.holidays[v.Format(DateFormat)] = true
+	}
+}
+
+func (c *BusinessCalendar) IsHoliday(t time.Time) bool {
+	return c.holidays[t.Format(DateFormat)]
+}
+
+func (c *BusinessCalendar) IsBusinessDay(t time.Time) bool {
+	return !c.weekend[t.Weekday()] && !c.IsHoliday(t)
+}
+
+// NextBusinessDay return the first business day after t, keeping t time of day
+func (c *BusinessCalendar) NextBusinessDay(t time.Time) time.Time {
+	return c.AddBusinessDays(t, 1)
+}
+
+// AddBusinessDays add n business days (negative n goes backward), keeping t time of day
+func (c *BusinessCalendar) AddBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step = -1
+		n = -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// BusinessDaysBetween count business days in [start, end), negative when end is before start
+func (c *BusinessCalendar) BusinessDaysBetween(start, end time.Time) int {
+	sign := 1
+	if end.Before(start) {
+		start, end = end, start
+		sign = -1
+	}
+
+	count := 0
+	for day := StartOfDay(start); day.Before(StartOfDay(end)); day = day.AddDate(0, 0, 1) {
+		if c.IsBusinessDay(day) {
+			count++
+		}
+	}
+	return count * sign
+}
+
+// NewDateRange create range between start and end day (inclusive), both are truncated to midnight
+func NewDateRange(start, end time.Time) DateRange {
+	return DateRange{Start: StartOfDay(start), End: StartOfDay(end)}
+}
+
+// Days return every date in range
+func (r DateRange) Days() []time.Time {
+	var days []time.Time
+	for day := r.Start; !day.After(r.End); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// Contains check whether t date is inside range
+func (r DateRange) Contains(t time.Time) bool {
+	day := StartOfDay(t.In(r.Start.Location()))
+	return !day.Before(r.Start) && !day.After(r.End)
+}
+
+func (r DateRange) Overlaps(other DateRange) bool {
+	return !r.Start.After(other.End) && !other.Start.After(r.End)
+}
+
+// BusinessDays return business days in range
+func (r DateRange) BusinessDays(c *BusinessCalendar) []time.Time {
+	var days []time.Time
+	for _, day := range r.Days() {
+		if c.IsBusinessDay(day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
@@ -0,0 +1,74 @@
+package timeutil
+
+import "time"
+
+const (
+	// DateFormat date only layout, eg: 2020-12-31
+	DateFormat = "2006-01-02"
+	// DateTimeFormat layout used across the packages, eg: 2020-12-31 23:59:59
+	DateTimeFormat = "2006-01-02 15:04:05"
+)
+
+// jakarta Asia/Jakarta location, fallback to fixed WIB (UTC+7) when tzdata is not available
+var jakarta = loadLocation("Asia/Jakarta", "WIB", 7*60*60)
+
+func loadLocation(name, abbreviation string, offset int) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone(abbreviation, offset)
+	}
+	return location
+}
+
+// Jakarta return Asia/Jakarta location, the default timezone of the helpers
+func Jakarta() *time.Location {
+	return jakarta
+}
+
+// InJakarta convert t into Asia/Jakarta time
+func InJakarta(t time.Time) time.Time {
+	return t.In(jakarta)
+}
+
+// ParseDate parse date (2006-01-02) in Asia/Jakarta timezone
+func ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation(DateFormat, value, jakarta)
+}
+
+// ParseDateTime parse date time (2006-01-02 15:04:05) in Asia/Jakarta timezone
+func ParseDateTime(value string) (time.Time, error) {
+	return time.ParseInLocation(DateTimeFormat, value, jakarta)
+}
+
+// Date create date at midnight in Asia/Jakarta timezone
+func Date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, jakarta)
+}
+
+// StartOfDay return midnight of t in t location
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// EndOfDay return last nanosecond of t day in t location
+func EndOfDay(t time.Time) time.Time {
+	return StartOfDay(t).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+func StartOfMonth(t time.Time) time.Time {
+	year, month, _ := t.Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+}
+
+func EndOfMonth(t time.Time) time.Time {
+	return StartOfMonth(t).AddDate(0, 1, 0).Add(-time.Nanosecond)
+}
+
+// SameDay check whether a and b are on the same date in a location
+func SameDay(a, b time.Time) bool {
+	b = b.In(a.Location())
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}